@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// backupVersion is the version of the backup document format written by backupStore.
+const backupVersion = 1
+
+// backup is a snapshot of the whole store, as written by backupStore and read by restoreStore.
+type backup struct {
+	Version   int                      `json:"version"`
+	CreatedAt time.Time                `json:"created_at"`
+	Tenants   map[string]libraryBackup `json:"tenants"`
+}
+
+// libraryBackup is the backed up content of a single tenant's library.
+type libraryBackup struct {
+	Books     []book     `json:"books"`
+	Checkouts []checkout `json:"checkouts"`
+}
+
+// restoreResponse is the response of a successful restore.
+type restoreResponse struct {
+	Message string `json:"message"`
+	Tenants int    `json:"tenants"`
+}
+
+// reindexResponse is the response of a successful reindex, mapping old to new book IDs.
+type reindexResponse struct {
+	Message string            `json:"message"`
+	Mapping map[string]string `json:"mapping"`
+}
+
+// backupStore streams the books and checkouts of every tenant as a single JSON document,
+// served as a file attachment so that it can be saved and later passed to restoreStore.
+// The document always uses snake_case field names, regardless of the configured field style,
+// so that it can be restored by any server.
+func backupStore(c *gin.Context) {
+	now := serverClock.Now().UTC()
+	doc := backup{Version: backupVersion, CreatedAt: now, Tenants: map[string]libraryBackup{}}
+
+	storeMu.RLock()
+	for tenant, lib := range libraries {
+		doc.Tenants[tenant] = libraryBackup{
+			Books:     cloneBooks(lib.books),
+			Checkouts: append([]checkout{}, lib.checkouts...),
+		}
+	}
+	storeMu.RUnlock()
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="backup-%s.json"`, now.Format("20060102T150405Z")))
+	c.Header("Content-Type", jsonContentType())
+	c.Status(http.StatusOK)
+
+	enc := json.NewEncoder(c.Writer)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(doc); err != nil {
+		c.Error(err)
+	}
+}
+
+// restoreStore replaces the whole store with the content of a backup document produced by backupStore.
+// The request must carry 'confirm=true' since every existing book and checkout is discarded.
+// It returns a 400 status code if the backup is malformed or inconsistent, in which case the store is left untouched.
+func restoreStore(c *gin.Context) {
+	if c.Query("confirm") != "true" {
+		respondError(c, http.StatusBadRequest, "restoring a backup requires query parameter 'confirm=true'")
+		return
+	}
+
+	var doc backup
+
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid backup: "+err.Error())
+		return
+	}
+
+	if err := doc.validate(); err != nil {
+		respondError(c, http.StatusBadRequest, "invalid backup: "+err.Error())
+		return
+	}
+
+	restored := make(map[string]*library, len(doc.Tenants))
+	for tenant, lb := range doc.Tenants {
+		lib := newLibrary(lb.Books)
+		lib.checkouts = append(lib.checkouts, lb.Checkouts...)
+		restored[tenant] = lib
+	}
+
+	storeMu.Lock()
+	libraries = restored
+	markStoreChanged()
+	storeMu.Unlock()
+
+	respondJSON(c, http.StatusOK, restoreResponse{Message: "success", Tenants: len(restored)})
+}
+
+// reindexBooks assigns fresh IDs to all of the tenant's books and updates their checkouts to match.
+// The 'scheme' query parameter selects the new IDs: 'uuid' (the default) for random UUIDs, or
+// 'sequential' for the integers 1, 2, 3, ... in the current order of the books.
+// The request must carry 'confirm=true' since clients holding the old IDs will no longer find the books.
+// It returns the mapping of old to new IDs.
+func reindexBooks(c *gin.Context) {
+	if c.Query("confirm") != "true" {
+		respondError(c, http.StatusBadRequest, "reindexing requires query parameter 'confirm=true'")
+		return
+	}
+
+	scheme := c.DefaultQuery("scheme", "uuid")
+	if scheme != "uuid" && scheme != "sequential" {
+		respondError(c, http.StatusBadRequest, "query parameter 'scheme' must be 'uuid' or 'sequential'")
+		return
+	}
+
+	lib := currentLibrary(c)
+	now := serverClock.Now()
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	mapping := make(map[string]string, len(lib.books))
+	for i := range lib.books {
+		b := &lib.books[i]
+
+		newID := newUUID()
+		if scheme == "sequential" {
+			newID = strconv.Itoa(i + 1)
+		}
+
+		mapping[b.ID] = newID
+		b.ID = newID
+		b.touch(now)
+	}
+
+	for i := range lib.checkouts {
+		lib.checkouts[i].BookID = mapping[lib.checkouts[i].BookID]
+	}
+	if len(mapping) > 0 {
+		markStoreChanged()
+	}
+
+	respondJSON(c, http.StatusOK, reindexResponse{Message: "success", Mapping: mapping})
+}
+
+// validate checks that the backup has a supported version and that every tenant's library
+// is internally consistent, as described by integrityViolations.
+func (b backup) validate() error {
+	if b.Version != backupVersion {
+		return fmt.Errorf("unsupported version %d", b.Version)
+	}
+	if b.Tenants == nil {
+		return fmt.Errorf("missing 'tenants'")
+	}
+
+	for tenant, lb := range b.Tenants {
+		if tenant == "" {
+			return fmt.Errorf("empty tenant ID")
+		}
+
+		lib := &library{books: lb.Books, checkouts: lb.Checkouts}
+		if violations := lib.integrityViolations(); len(violations) > 0 {
+			return fmt.Errorf("tenant %q: %s", tenant, violations[0])
+		}
+	}
+
+	return nil
+}
+
+// getConfig returns the effective runtime configuration of the server, with secrets such as
+// the admin API key redacted.
+func getConfig(c *gin.Context) {
+	respondJSON(c, http.StatusOK, currentConfig())
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Channels","author":"Mr. Channel","quantity":3}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"x","title":"Tenant book"}`, "X-Tenant-ID", "acme"), http.StatusCreated)
+
+	w := serve(router, http.MethodGet, "/admin/backup", "", "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusOK)
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+		t.Errorf("Content-Disposition = %q, want an attachment", cd)
+	}
+	body := w.Body.String()
+	before := decode[backup](t, w)
+
+	resetStore()
+	expectStatus(t, serve(router, http.MethodPost, "/admin/restore", body, "X-API-Key", "secret"), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodPost, "/admin/restore?confirm=true", body, "X-API-Key", "secret"), http.StatusOK)
+
+	after := decode[backup](t, serve(router, http.MethodGet, "/admin/backup", "", "X-API-Key", "secret"))
+	if !reflect.DeepEqual(before.Tenants, after.Tenants) {
+		t.Errorf("restored store differs from the backup:\nbefore: %+v\nafter:  %+v", before.Tenants, after.Tenants)
+	}
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	t.Setenv("ERROR_FORMAT", errorFormatProblem)
+	router := newTestRouter(t)
+
+	for name, body := range map[string]string{
+		"malformed":       `{"version":`,
+		"wrong version":   `{"version":2,"tenants":{}}`,
+		"unknown book":    `{"version":1,"tenants":{"default":{"books":[],"checkouts":[{"book_id":"1"}]}}}`,
+		"duplicate IDs":   `{"version":1,"tenants":{"default":{"books":[{"id":"1"},{"id":"1"}],"checkouts":[]}}}`,
+		"missing tenants": `{"version":1}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(router, http.MethodPost, "/admin/restore?confirm=true", body, "X-API-Key", "secret")
+			expectStatus(t, w, http.StatusBadRequest)
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+				t.Errorf("Content-Type = %q, want a problem", ct)
+			}
+			expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusOK)
+		})
+	}
+}
+
+func TestConfigReportsRateLimits(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	t.Setenv("RATE_LIMITS", "reads=100/s,writes=10/m")
+	router := newTestRouter(t)
+
+	cfg := decode[effectiveConfig](t, serve(router, http.MethodGet, "/admin/config", "", "X-API-Key", "secret"))
+	if want := map[string]string{"reads": "100/s", "writes": "10/m"}; !reflect.DeepEqual(cfg.RateLimits, want) {
+		t.Errorf("rate_limits = %v, want %v", cfg.RateLimits, want)
+	}
+}
+
+func TestConfigReportsEverySettingAndRedactsSecrets(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	t.Setenv("ALERT_WEBHOOK", "https://hooks.example.com/token")
+	t.Setenv("DEFAULT_AUTHOR", "Anonymous")
+	t.Setenv("STRICT_JSON", "true")
+	t.Setenv("FEATURE_EXPORT", "false")
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/admin/config", "", "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusOK)
+	if strings.Contains(w.Body.String(), "hooks.example.com") || strings.Contains(w.Body.String(), `"secret"`) {
+		t.Errorf("config leaks a secret: %s", w.Body.String())
+	}
+
+	cfg := decode[effectiveConfig](t, w)
+	if cfg.AdminAPIKey != redacted || cfg.AlertWebhook != redacted {
+		t.Errorf("admin_api_key = %q, alert_webhook = %q, want both %q", cfg.AdminAPIKey, cfg.AlertWebhook, redacted)
+	}
+	if cfg.DefaultAuthor != "Anonymous" || !cfg.StrictJSON {
+		t.Errorf("default_author = %q, strict_json = %t", cfg.DefaultAuthor, cfg.StrictJSON)
+	}
+	if enabled, ok := cfg.Features["export"]; !ok || enabled {
+		t.Errorf("features = %v, want export disabled", cfg.Features)
+	}
+}
+
+func TestReindexKeepsCheckoutsConsistent(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+
+	expectStatus(t, serve(router, http.MethodPost, "/admin/reindex", "", "X-API-Key", "secret"), http.StatusBadRequest)
+
+	w := serve(router, http.MethodPost, "/admin/reindex?confirm=true", "", "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusOK)
+	mapping := decode[reindexResponse](t, w).Mapping
+	if len(mapping) != 4 || mapping["2"] == "" || mapping["2"] == "2" {
+		t.Fatalf("mapping = %v, want a new ID for each of the 4 books", mapping)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/2", ""), http.StatusNotFound)
+	if got := checkoutUsers(t, router, mapping["2"]); len(got) != 1 || got[0] != "ann" {
+		t.Errorf("checkouts of the reindexed book = %v, want ann's", got)
+	}
+
+	w = serve(router, http.MethodPost, "/admin/reindex?confirm=true&scheme=sequential", "", "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusOK)
+	if got := decode[reindexResponse](t, w).Mapping[mapping["2"]]; got != "2" {
+		t.Errorf("sequential ID of the second book = %q, want 2", got)
+	}
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2&user=ann", ""), http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// alertTimeout bounds how long sending a single alert may take.
+const alertTimeout = 5 * time.Second
+
+// maxAlertErrorLength is the length to which the error of an alert is truncated.
+const maxAlertErrorLength = 200
+
+// panicAlert is the JSON payload posted to alertWebhook when a handler panics.
+type panicAlert struct {
+	Service   string    `json:"service"`
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Error     string    `json:"error"`
+	Time      time.Time `json:"time"`
+}
+
+// alertClient sends alerts to alertWebhook.
+var alertClient = &http.Client{Timeout: alertTimeout}
+
+// recoverWithAlert returns a middleware that recovers from panics in handlers like gin.Recovery,
+// responding with status code 500, and, if alertWebhook is configured, posts a panicAlert there
+// so that on-call gets notified. The alert is sent in the background, so it never delays the response.
+func recoverWithAlert() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		if alertWebhook != "" {
+			go sendPanicAlert(panicAlert{
+				Service:   serviceName,
+				RequestID: c.GetString(requestIDKey),
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Error:     sanitizeAlertError(recovered),
+				Time:      time.Now().UTC(),
+			})
+		}
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// sanitizeAlertError renders a recovered panic value as a single line of at most
+// maxAlertErrorLength bytes, so that alerts stay readable and never carry a stack trace.
+func sanitizeAlertError(recovered any) string {
+	msg := strings.Join(strings.Fields(fmt.Sprint(recovered)), " ")
+	if len(msg) > maxAlertErrorLength {
+		msg = msg[:maxAlertErrorLength] + "..."
+	}
+	return msg
+}
+
+// sendPanicAlert posts alert to alertWebhook. Failures are logged, not retried.
+func sendPanicAlert(alert panicAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		slog.Error("encoding panic alert failed", "error", err)
+		return
+	}
+
+	resp, err := alertClient.Post(alertWebhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("sending panic alert failed", "error", err, "request_id", alert.RequestID)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		slog.Error("panic alert rejected by webhook", "status", resp.StatusCode, "request_id", alert.RequestID)
+	}
+}
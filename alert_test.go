@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveredPanicsAreSentToAlertWebhook(t *testing.T) {
+	defer func(w io.Writer) { gin.DefaultErrorWriter = w }(gin.DefaultErrorWriter)
+	gin.DefaultErrorWriter = io.Discard
+
+	alerts := make(chan panicAlert, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert panicAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("decoding alert: %v", err)
+		}
+		alerts <- alert
+	}))
+	defer webhook.Close()
+
+	t.Setenv("ALERT_WEBHOOK", webhook.URL)
+	router := newTestRouter(t)
+	router.GET("/panic", func(*gin.Context) { panic("boom\n\tat line 1") })
+	expectStatus(t, serve(router, http.MethodGet, "/panic", "", "X-Request-ID", "req-1"), http.StatusInternalServerError)
+
+	select {
+	case alert := <-alerts:
+		if alert.RequestID != "req-1" || alert.Method != http.MethodGet || alert.Path != "/panic" || alert.Error != "boom at line 1" {
+			t.Errorf("alert = %+v, want the request and the panic on a single line", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert was posted to the webhook")
+	}
+}
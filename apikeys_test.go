@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScopedAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"partner-key":["read"],"ops-key":["read","write"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("API_KEYS_FILE", path)
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/1", "", "X-API-Key", "partner-key"), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Partner"}`, "X-API-Key", "partner-key"), http.StatusForbidden)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Ops"}`, "X-API-Key", "ops-key"), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Admin"}`, "X-API-Key", "secret"), http.StatusCreated)
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusUnauthorized)
+	expectStatus(t, serve(router, http.MethodGet, "/books/1", "", "X-API-Key", "unknown"), http.StatusUnauthorized)
+}
+
+func TestLoadAPIKeysRejectsUnknownScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte(`{"partner-key":["delete"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAPIKeys(path); err == nil {
+		t.Error("loadAPIKeys accepted an unknown scope")
+	}
+}
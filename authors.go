@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authorGroup is a single author together with their books, as returned by getBooksByAuthor.
+type authorGroup struct {
+	Author string `json:"author"`
+	Count  int    `json:"count"`
+	Books  []book `json:"books"`
+}
+
+// authorsPage is a page of author groups returned by getBooksByAuthor.
+type authorsPage struct {
+	Authors      []authorGroup `json:"authors"`
+	Page         int           `json:"page"`
+	PerPage      int           `json:"per_page"`
+	TotalAuthors int           `json:"total_authors"`
+}
+
+// getBooksByAuthor returns the tenant's books grouped by author.
+// Authors are sorted alphabetically, or in reverse with 'order=desc', and the books of each
+// author are sorted by title. The result is paginated by author with the 'page' and 'per_page'
+// query parameters, and accepts the same search query parameters as getBooks. With 'strict_paging=true',
+// a page past the last one is rejected as described by checkPageInRange.
+func getBooksByAuthor(c *gin.Context) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		respondError(c, http.StatusBadRequest, "query parameter 'order' must be 'asc' or 'desc'")
+		return
+	}
+
+	byAuthor := map[string][]book{}
+	for _, b := range filterBooks(c) {
+		byAuthor[b.Author] = append(byAuthor[b.Author], b)
+	}
+
+	groups := make([]authorGroup, 0, len(byAuthor))
+	for author, list := range byAuthor {
+		sort.SliceStable(list, func(i, j int) bool {
+			return strings.ToLower(list[i].Title) < strings.ToLower(list[j].Title)
+		})
+		groups = append(groups, authorGroup{Author: author, Count: len(list), Books: list})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := strings.ToLower(groups[i].Author), strings.ToLower(groups[j].Author)
+		if a == b {
+			a, b = groups[i].Author, groups[j].Author
+		}
+		if order == "desc" {
+			return a > b
+		}
+		return a < b
+	})
+
+	if !checkPageInRange(c, page, perPage, len(groups)) {
+		return
+	}
+
+	respondJSON(c, http.StatusOK, authorsPage{
+		Authors:      paginate(groups, page, perPage),
+		Page:         page,
+		PerPage:      perPage,
+		TotalAuthors: len(groups),
+	})
+}
+
+// authorSummary is a single author with the number of their titles and of their copies in stock,
+// as returned by getAuthors.
+type authorSummary struct {
+	Author string `json:"author"`
+	Titles int    `json:"titles"`
+	Copies int    `json:"copies"`
+}
+
+// authorSummariesPage is a page of author summaries returned by getAuthors.
+type authorSummariesPage struct {
+	Authors      []authorSummary `json:"authors"`
+	Page         int             `json:"page"`
+	PerPage      int             `json:"per_page"`
+	TotalAuthors int             `json:"total_authors"`
+}
+
+// getAuthors returns the distinct authors of the tenant's books, each with the number of their
+// titles and the total quantity of their books, for an author directory. Unlike getBooksByAuthor
+// it does not return the books themselves.
+// Authors are sorted alphabetically and paginated with the 'page' and 'per_page' query parameters,
+// and accept 'strict_paging' like getBooksByAuthor.
+// The optional 'search' query parameter keeps only authors whose name contains it, ignoring case.
+func getAuthors(c *gin.Context) {
+	page, perPage, err := parsePagination(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	search := strings.ToLower(c.Query("search"))
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	byAuthor := map[string]*authorSummary{}
+	for _, b := range lib.books {
+		if search != "" && !strings.Contains(strings.ToLower(b.Author), search) {
+			continue
+		}
+
+		summary, ok := byAuthor[b.Author]
+		if !ok {
+			summary = &authorSummary{Author: b.Author}
+			byAuthor[b.Author] = summary
+		}
+		summary.Titles++
+		summary.Copies += int(b.Quantity)
+	}
+	storeMu.RUnlock()
+
+	summaries := make([]authorSummary, 0, len(byAuthor))
+	for _, summary := range byAuthor {
+		summaries = append(summaries, *summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := strings.ToLower(summaries[i].Author), strings.ToLower(summaries[j].Author)
+		if a == b {
+			return summaries[i].Author < summaries[j].Author
+		}
+		return a < b
+	})
+
+	if !checkPageInRange(c, page, perPage, len(summaries)) {
+		return
+	}
+
+	respondJSON(c, http.StatusOK, authorSummariesPage{
+		Authors:      paginate(summaries, page, perPage),
+		Page:         page,
+		PerPage:      perPage,
+		TotalAuthors: len(summaries),
+	})
+}
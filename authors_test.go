@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetBooksByAuthorGroupsBooks(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Generics","author":"Mr. Golang","quantity":1}`), http.StatusCreated)
+
+	w := serve(router, http.MethodGet, "/books/by-author?per_page=2", "")
+	expectStatus(t, w, http.StatusOK)
+	page := decode[authorsPage](t, w)
+	if page.TotalAuthors != 4 || len(page.Authors) != 2 {
+		t.Fatalf("page = %+v, want 2 of 4 authors", page)
+	}
+
+	first := page.Authors[0]
+	if first.Author != "Mr. Currency" || first.Count != 1 {
+		t.Errorf("first group = %s with %d books, want Mr. Currency with 1", first.Author, first.Count)
+	}
+	golang := page.Authors[1]
+	if golang.Author != "Mr. Golang" || golang.Count != 2 || golang.Books[0].Title != "Generics" || golang.Books[1].Title != "Golang pointers" {
+		t.Errorf("second group = %+v, want Mr. Golang's 2 books sorted by title", golang)
+	}
+
+	page = decode[authorsPage](t, serve(router, http.MethodGet, "/books/by-author?per_page=2&page=2&order=desc", ""))
+	if len(page.Authors) != 2 || page.Authors[0].Author != "Mr. Golang" || page.Authors[1].Author != "Mr. Currency" {
+		t.Errorf("second page in descending order = %+v, want Mr. Golang and Mr. Currency", page.Authors)
+	}
+}
+
+func TestGetBooksByAuthorHugePage(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/books/by-author?page=100000000000000001&per_page=100", "")
+	expectStatus(t, w, http.StatusOK)
+	if page := decode[authorsPage](t, w); len(page.Authors) != 0 || page.TotalAuthors != 4 {
+		t.Errorf("page = %+v, want an empty page of 4 authors", page)
+	}
+}
+
+func TestGetAuthorsSummarizesSeedBooks(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Generics","author":"Mr. Golang","quantity":3}`), http.StatusCreated)
+
+	w := serve(router, http.MethodGet, "/authors", "")
+	expectStatus(t, w, http.StatusOK)
+	want := []authorSummary{
+		{Author: "Mr. Currency", Titles: 1, Copies: 40},
+		{Author: "Mr. Golang", Titles: 2, Copies: 5},
+		{Author: "Mr. Goroutine", Titles: 1, Copies: 20},
+		{Author: "Mr. Router", Titles: 1, Copies: 30},
+	}
+	if page := decode[authorSummariesPage](t, w); page.TotalAuthors != 4 || !reflect.DeepEqual(page.Authors, want) {
+		t.Errorf("page = %+v, want %+v", page, want)
+	}
+
+	page := decode[authorSummariesPage](t, serve(router, http.MethodGet, "/authors?search=GO&per_page=1&page=2", ""))
+	if page.TotalAuthors != 2 || !reflect.DeepEqual(page.Authors, want[2:3]) {
+		t.Errorf("second page of authors matching GO = %+v, want Mr. Goroutine of 2 authors", page)
+	}
+
+	page = decode[authorSummariesPage](t, serve(router, http.MethodGet, "/authors?page=100000000000000001&per_page=100", ""))
+	if len(page.Authors) != 0 || page.TotalAuthors != 4 {
+		t.Errorf("huge page = %+v, want an empty page of 4 authors", page)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// printBanner writes a startup banner to w listing every registered route, sorted by path and
+// method, followed by the effective configuration with secrets redacted, so that it is easy to
+// verify which endpoints and features a deployment has enabled.
+func printBanner(w io.Writer, routes gin.RoutesInfo, cfg effectiveConfig) error {
+	sorted := append(gin.RoutesInfo{}, routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	fmt.Fprintf(w, "Routes (%d):\n", len(sorted))
+	for _, r := range sorted {
+		fmt.Fprintf(w, "  %-7s %s\n", r.Method, r.Path)
+	}
+
+	config, err := json.MarshalIndent(cfg, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Configuration:\n  %s\n", config)
+	return err
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPrintBannerListsRoutes(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "top-secret-key")
+	router := newTestRouter(t)
+
+	var buf bytes.Buffer
+	if err := printBanner(&buf, router.Routes(), currentConfig()); err != nil {
+		t.Fatalf("printBanner: %v", err)
+	}
+	out := buf.String()
+
+	for _, route := range []string{"GET /books", "POST /books", "GET /books/:id", "PATCH /checkout", "PATCH /return", "GET /metrics", "GET /admin/config"} {
+		method, path, _ := strings.Cut(route, " ")
+		if !regexp.MustCompile(`(?m)^  ` + method + ` +` + regexp.QuoteMeta(path) + `$`).MatchString(out) {
+			t.Errorf("banner does not list %s:\n%s", route, out)
+		}
+	}
+	if !strings.Contains(out, "Configuration:") || !strings.Contains(out, redacted) || strings.Contains(out, "top-secret-key") {
+		t.Errorf("banner does not show the configuration with the admin API key redacted:\n%s", out)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batchReturnRequest is the JSON payload of returnBooksBatch.
+type batchReturnRequest struct {
+	User string   `json:"user" binding:"required"`
+	IDs  []string `json:"ids" binding:"required,min=1,dive,required"`
+}
+
+// batchItemResult reports the outcome of a batch operation for a single book ID.
+type batchItemResult struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// batchResponse is the response of a successful batch operation.
+type batchResponse struct {
+	Message string            `json:"message"`
+	Results []batchItemResult `json:"results"`
+}
+
+// returnBooksBatch returns several books checked out by the same user in one call.
+// It expects a JSON payload in the request body with the following format:
+//
+//	{
+//	  "user": "string",
+//	  "ids": ["string"]
+//	}
+//
+// By default the batch is atomic: every book must exist and be checked out by the user, otherwise
+// nothing is returned and a 422 status code is sent with the result of each ID.
+// With the query parameter 'partial=true', the batch is applied on a best-effort basis instead:
+// the books that can be returned are, and the result of each ID is sent with status code 207 (Multi-Status).
+// An ID may be listed several times if the user holds several copies of the book.
+// On success the user's checkouts are cleared, the quantities are incremented, and
+// the per-ID results are returned with status code 200 (OK).
+func returnBooksBatch(c *gin.Context) {
+	var req batchReturnRequest
+
+	partial, err := strconv.ParseBool(c.DefaultQuery("partial", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("partial", "a boolean").Error())
+		return
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	// Clear the checkouts on a scratch copy first so that nothing changes unless every ID succeeds.
+	scratch := &library{checkouts: append([]checkout{}, lib.checkouts...)}
+	cleared := make([]checkout, 0, len(req.IDs))
+	results := make([]batchItemResult, len(req.IDs))
+	failed := false
+
+	for i, id := range req.IDs {
+		results[i] = batchItemResult{ID: id, OK: true}
+
+		if _, err := lib.getBookById(id); err != nil {
+			results[i] = batchItemResult{ID: id, Error: "book not found"}
+			failed = true
+			continue
+		}
+
+		co, err := scratch.clearCheckout(id, req.User)
+		if err != nil {
+			results[i] = batchItemResult{ID: id, Error: err.Error()}
+			failed = true
+			continue
+		}
+		cleared = append(cleared, co)
+	}
+
+	if failed && !partial {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, "no books were returned", errorDetails{Results: results})
+		return
+	}
+
+	now := serverClock.Now()
+	lib.checkouts = scratch.checkouts
+	for _, co := range cleared {
+		book, _ := lib.getBookById(co.BookID)
+		book.returnCopy(co.Barcode)
+		book.touch(now)
+	}
+	if len(cleared) > 0 {
+		markStoreChanged()
+	}
+
+	if partial {
+		message := "success"
+		if failed {
+			message = "some books were not returned"
+		}
+		respondJSON(c, http.StatusMultiStatus, batchResponse{Message: message, Results: results})
+		return
+	}
+
+	respondJSON(c, http.StatusOK, batchResponse{Message: "success", Results: results})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestReturnBooksBatchIsAtomic(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=bob", ""), http.StatusOK)
+
+	w := serve(router, http.MethodPost, "/return/batch", `{"user":"ann","ids":["1","2"]}`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	results := decode[errorResponse](t, w).Results
+	if len(results) != 2 || !results[0].OK || results[1].OK || results[1].Error == "" {
+		t.Fatalf("results = %+v, want book 1 ok and book 2 failed", results)
+	}
+	if got := checkoutUsers(t, router, "1"); len(got) != 1 {
+		t.Errorf("checkouts of book 1 = %v, want ann's checkout kept", got)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 1 {
+		t.Errorf("quantity of book 1 = %d, want 1", b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+	w = serve(router, http.MethodPost, "/return/batch", `{"user":"ann","ids":["1","2"]}`)
+	expectStatus(t, w, http.StatusOK)
+	if got := checkoutUsers(t, router, "2"); len(got) != 1 || got[0] != "bob" {
+		t.Errorf("checkouts of book 2 = %v, want only bob's", got)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 2 {
+		t.Errorf("quantity of book 1 = %d, want 2", b.Quantity)
+	}
+}
+
+func TestReturnBooksBatchPartial(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=ann", ""), http.StatusOK)
+
+	w := serve(router, http.MethodPost, "/return/batch?partial=true", `{"user":"ann","ids":["1","2","99"]}`)
+	expectStatus(t, w, http.StatusMultiStatus)
+	results := decode[batchResponse](t, w).Results
+	if len(results) != 3 || !results[0].OK || results[1].OK || results[2].OK {
+		t.Fatalf("results = %+v, want only book 1 returned", results)
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 2 {
+		t.Errorf("quantity of book 1 = %d, want the return to persist", b.Quantity)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); b.Quantity != 20 {
+		t.Errorf("quantity of book 2 = %d, want it untouched", b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/return/batch?partial=maybe", `{"user":"ann","ids":["1"]}`), http.StatusBadRequest)
+}
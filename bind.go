@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindBookJSON decodes the JSON request body into obj and validates it like c.ShouldBindJSON.
+// If strict JSON decoding is configured, fields that obj does not have are rejected, so that
+// typos in request bodies do not go unnoticed; the error names the offending field.
+func bindBookJSON(c *gin.Context, obj any) error {
+	if !strictJSON {
+		return c.ShouldBindJSON(obj)
+	}
+
+	if c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
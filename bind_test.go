@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStrictJSONRejectsUnknownFields(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","titl":"x"}`), http.StatusCreated)
+
+	t.Setenv("STRICT_JSON", "true")
+	router = newTestRouter(t)
+
+	w := serve(router, http.MethodPost, "/books", `{"id":"5","titl":"x"}`)
+	expectStatus(t, w, http.StatusBadRequest)
+	if msg := decode[errorResponse](t, w).Message; !strings.Contains(msg, `"titl"`) {
+		t.Errorf("message = %q, want it to name the unknown field", msg)
+	}
+	expectStatus(t, serve(router, http.MethodPut, "/books/1", `{"id":"1","title":"x","quantity":1,"extra":true}`), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"x"}`), http.StatusCreated)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// maxListCacheEntries bounds the number of distinct queries listCache holds for a store generation.
+const maxListCacheEntries = 1000
+
+// listCacheEntry is a serialized getBooks response together with its ETag.
+// empty is set if the response lists no books.
+type listCacheEntry struct {
+	etag  string
+	body  []byte
+	empty bool
+}
+
+// responseCache holds serialized responses keyed by tenant and normalized query.
+// Every entry belongs to the same store generation; entries of an older generation are
+// discarded as soon as a response of a newer generation is stored.
+type responseCache struct {
+	mu         sync.Mutex
+	generation uint64
+	entries    map[string]listCacheEntry
+}
+
+// listCache caches the responses of getBooks until the store is next modified.
+var listCache responseCache
+
+// get returns the entry cached under key, if there is one for the given store generation.
+func (rc *responseCache) get(key string, generation uint64) (listCacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.generation != generation {
+		return listCacheEntry{}, false
+	}
+	entry, ok := rc.entries[key]
+	return entry, ok
+}
+
+// put caches body, which lists no books if empty is set, under key for the given store generation
+// and returns the entry with its ETag. Responses of a generation older than the cached ones are not stored.
+func (rc *responseCache) put(key string, generation uint64, body []byte, empty bool) listCacheEntry {
+	sum := sha256.Sum256(body)
+	entry := listCacheEntry{etag: `"` + hex.EncodeToString(sum[:16]) + `"`, body: body, empty: empty}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if generation > rc.generation || rc.entries == nil {
+		rc.generation = generation
+		rc.entries = make(map[string]listCacheEntry)
+	}
+	if generation == rc.generation && len(rc.entries) < maxListCacheEntries {
+		rc.entries[key] = entry
+	}
+	return entry
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetBooksServedFromCache(t *testing.T) {
+	router := newTestRouter(t)
+
+	first := serve(router, http.MethodGet, "/books?title=golang", "")
+	expectStatus(t, first, http.StatusOK)
+	etag := first.Header().Get("ETag")
+
+	// While a writer holds the store, only cached responses can be served.
+	storeMu.Lock()
+	done := make(chan [2]*httptest.ResponseRecorder)
+	go func() {
+		done <- [2]*httptest.ResponseRecorder{
+			serve(router, http.MethodGet, "/books?title=golang", ""),
+			serve(router, http.MethodGet, "/books?title=golang", "", "If-None-Match", etag),
+		}
+	}()
+	var responses [2]*httptest.ResponseRecorder
+	select {
+	case responses = <-done:
+		storeMu.Unlock()
+	case <-time.After(5 * time.Second):
+		storeMu.Unlock()
+		t.Fatal("cached list request read the store")
+	}
+
+	expectStatus(t, responses[0], http.StatusOK)
+	if responses[0].Body.String() != first.Body.String() || responses[0].Header().Get("ETag") != etag {
+		t.Error("cached response differs from the original")
+	}
+	expectStatus(t, responses[1], http.StatusNotModified)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Golang generics"}`), http.StatusCreated)
+	w := serve(router, http.MethodGet, "/books?title=golang", "", "If-None-Match", etag)
+	expectStatus(t, w, http.StatusOK)
+	if books := decode[[]book](t, w); len(books) != 4 {
+		t.Errorf("listed %d books after a write, want 4", len(books))
+	}
+}
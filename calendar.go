@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCalendarDays is the number of days projected by getAvailabilityCalendar when 'days' is not given.
+const defaultCalendarDays = 14
+
+// maxCalendarDays is the largest 'days' accepted by getAvailabilityCalendar.
+const maxCalendarDays = 90
+
+// calendarDay is the projected availability of a book at the end of a single day.
+type calendarDay struct {
+	Date      string   `json:"date"`
+	Available quantity `json:"available"`
+}
+
+// availabilityCalendar is the response of getAvailabilityCalendar.
+type availabilityCalendar struct {
+	BookID string        `json:"book_id"`
+	Days   []calendarDay `json:"days"`
+}
+
+// getAvailabilityCalendar projects how many copies of the book with the ID given in the path will be
+// available for checkout at the end of each of the next 'days' days (default defaultCalendarDays, at
+// most maxCalendarDays), starting today (UTC), assuming every outstanding checkout is returned on its
+// due date and no further checkouts are made. Overdue checkouts are not expected back.
+// It returns a 404 status code if the book does not exist.
+func getAvailabilityCalendar(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultCalendarDays)))
+	if err != nil || days < 1 || days > maxCalendarDays {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("days", "an integer between 1 and "+strconv.Itoa(maxCalendarDays)).Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+	now := serverClock.Now().UTC()
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	b, err := lib.getBookById(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+
+	var due []time.Time
+	for _, co := range lib.checkouts {
+		if co.BookID == b.ID && !co.DueAt.Before(now) {
+			due = append(due, co.DueAt)
+		}
+	}
+
+	projected := *b
+	calendar := availabilityCalendar{BookID: b.ID, Days: make([]calendarDay, 0, days)}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < days; i++ {
+		day := today.AddDate(0, 0, i)
+		end := day.AddDate(0, 0, 1)
+
+		for _, d := range due {
+			if !d.Before(day) && d.Before(end) {
+				projected.Quantity++
+			}
+		}
+		calendar.Days = append(calendar.Days, calendarDay{Date: day.Format(time.DateOnly), Available: projected.availableForCheckout()})
+	}
+
+	respondJSON(c, http.StatusOK, calendar)
+}
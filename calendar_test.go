@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAvailabilityCalendarProjectsReturnsOnDueDates(t *testing.T) {
+	stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"loan_days":5}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=bob", ""), http.StatusOK)
+
+	w := serve(router, http.MethodGet, "/books/1/availability-calendar?days=7", "")
+	expectStatus(t, w, http.StatusOK)
+	want := availabilityCalendar{BookID: "1", Days: []calendarDay{
+		{"2024-03-01", 0}, {"2024-03-02", 0}, {"2024-03-03", 0}, {"2024-03-04", 0},
+		{"2024-03-05", 0}, {"2024-03-06", 2}, {"2024-03-07", 2},
+	}}
+	if got := decode[availabilityCalendar](t, w); !reflect.DeepEqual(got, want) {
+		t.Errorf("calendar = %+v, want %+v", got, want)
+	}
+
+	if got := decode[availabilityCalendar](t, serve(router, http.MethodGet, "/books/1/availability-calendar", "")); len(got.Days) != defaultCalendarDays {
+		t.Errorf("projected %d days by default, want %d", len(got.Days), defaultCalendarDays)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/1/availability-calendar?days=0", ""), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodGet, "/books/404/availability-calendar", ""), http.StatusNotFound)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chaosLatencyMiddleware returns a middleware that delays every request by chaosLatency, or by
+// a random duration up to chaosLatency if chaosLatencyRandom is set, so that client timeouts and
+// retries can be tested against a real server. The delay ends early if the client goes away.
+// It is only installed when enableChaos is set.
+func chaosLatencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		delay := chaosLatency
+		if chaosLatencyRandom {
+			delay = time.Duration(rand.Int63n(int64(chaosLatency) + 1))
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestChaosLatencyRequiresEnableChaos(t *testing.T) {
+	const latency = 200 * time.Millisecond
+	t.Setenv("CHAOS_LATENCY_MS", strconv.Itoa(int(latency.Milliseconds())))
+
+	for _, enabled := range []bool{false, true} {
+		t.Setenv("ENABLE_CHAOS", strconv.FormatBool(enabled))
+		router := newTestRouter(t)
+
+		start := time.Now()
+		expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusOK)
+		if elapsed := time.Since(start); enabled != (elapsed >= latency) {
+			t.Errorf("ENABLE_CHAOS=%t: request took %s with CHAOS_LATENCY_MS=%d", enabled, elapsed, latency.Milliseconds())
+		}
+	}
+}
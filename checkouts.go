@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkout records a single copy of a book that has been checked out and not yet returned.
+type checkout struct {
+	BookID       string    `json:"book_id"`
+	User         string    `json:"user,omitempty"`
+	Barcode      string    `json:"barcode,omitempty"`
+	CheckedOutAt time.Time `json:"checked_out_at"`
+	DueAt        time.Time `json:"due_at"`
+}
+
+// recordCheckout appends a new outstanding checkout of the book for user, due back after
+// the book's loan period, and increments the book's borrow count. barcode identifies the copy
+// checked out, if the book tracks copies.
+// The library's checkouts are kept oldest first.
+// Callers must hold storeMu.
+func (l *library) recordCheckout(b *book, user, barcode string) checkout {
+	now := serverClock.Now()
+	co := checkout{BookID: b.ID, User: user, Barcode: barcode, CheckedOutAt: now, DueAt: now.Add(b.loanPeriod())}
+	l.checkouts = append(l.checkouts, co)
+	b.BorrowCount++
+	return co
+}
+
+// clearCheckout removes the oldest outstanding checkout of the book with the given id.
+// If user is not empty, only checkouts held by that user are considered.
+// It returns the removed checkout, or an error if no matching checkout exists.
+// Callers must hold storeMu.
+func (l *library) clearCheckout(bookID, user string) (checkout, error) {
+	for i, co := range l.checkouts {
+		if co.BookID != bookID || (user != "" && co.User != user) {
+			continue
+		}
+		l.checkouts = append(l.checkouts[:i], l.checkouts[i+1:]...)
+		return co, nil
+	}
+
+	if user != "" {
+		return checkout{}, errors.New("book is not checked out by this user")
+	}
+	return checkout{}, errors.New("book has no outstanding checkouts")
+}
+
+// isCheckedOut reports whether the book with the given id has any outstanding checkout.
+// Callers must hold storeMu.
+func (l *library) isCheckedOut(bookID string) bool {
+	return slices.ContainsFunc(l.checkouts, func(co checkout) bool { return co.BookID == bookID })
+}
+
+// dueCheckout is an outstanding checkout together with the title and author of the book, as listed by getCheckoutsDueToday.
+type dueCheckout struct {
+	checkout
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// getCheckoutsDueToday returns the tenant's outstanding checkouts that are due back today (UTC),
+// with the title and author of each book, soonest due first. The optional 'date' query parameter,
+// in YYYY-MM-DD format, lists the checkouts due on another day instead, e.g. to plan ahead.
+// It returns an empty list if nothing is due.
+func getCheckoutsDueToday(c *gin.Context) {
+	now := serverClock.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v, ok := c.GetQuery("date"); ok {
+		var err error
+		if day, err = time.Parse(time.DateOnly, v); err != nil {
+			respondError(c, http.StatusBadRequest, errInvalidQuery("date", "a date in YYYY-MM-DD format").Error())
+			return
+		}
+	}
+	end := day.AddDate(0, 0, 1)
+
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	result := []dueCheckout{}
+	for _, co := range lib.checkouts {
+		if co.DueAt.Before(day) || !co.DueAt.Before(end) {
+			continue
+		}
+
+		due := dueCheckout{checkout: co}
+		if b, err := lib.getBookById(co.BookID); err == nil {
+			due.Title, due.Author = b.Title, b.Author
+		}
+		result = append(result, due)
+	}
+	storeMu.RUnlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DueAt.Before(result[j].DueAt)
+	})
+
+	respondJSON(c, http.StatusOK, result)
+}
+
+// autoReturnOverdue returns, across all tenants, every book whose checkout was due more than
+// grace before now: the checkout is cleared and the book's quantity is incremented.
+// It returns the number of books that were returned.
+func autoReturnOverdue(now time.Time, grace time.Duration) int {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	returned := 0
+	for tenant, lib := range libraries {
+		remaining := lib.checkouts[:0]
+		for _, co := range lib.checkouts {
+			if now.Sub(co.DueAt) <= grace {
+				remaining = append(remaining, co)
+				continue
+			}
+
+			if book, err := lib.getBookById(co.BookID); err == nil {
+				book.returnCopy(co.Barcode)
+				book.touch(now)
+			}
+			returned++
+			slog.Info("auto-returned overdue book", "tenant", tenant, "book_id", co.BookID, "user", co.User, "due_at", co.DueAt)
+		}
+		lib.checkouts = remaining
+	}
+	if returned > 0 {
+		markStoreChanged()
+	}
+
+	return returned
+}
+
+// runAutoReturn calls autoReturnOverdue every interval until ctx is cancelled.
+func runAutoReturn(ctx context.Context, interval, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			autoReturnOverdue(serverClock.Now(), grace)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// checkoutUsers returns the users of the outstanding checkouts of the book with the given id, oldest first.
+func checkoutUsers(t *testing.T, router http.Handler, id string) []string {
+	t.Helper()
+
+	w := serve(router, http.MethodGet, "/books/"+id+"?expand=checkouts", "")
+	expectStatus(t, w, http.StatusOK)
+	users := []string{}
+	for _, co := range decode[struct{ Checkouts []checkout }](t, w).Checkouts {
+		users = append(users, co.User)
+	}
+	return users
+}
+
+func TestReturnBookClearsCheckouts(t *testing.T) {
+	router := newTestRouter(t)
+	clk := stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+
+	for _, user := range []string{"ann", "bob", "cid"} {
+		expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user="+user, ""), http.StatusOK)
+		clk.now = clk.now.Add(time.Minute)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2&user=bob", ""), http.StatusOK)
+	if got := checkoutUsers(t, router, "2"); len(got) != 2 || got[0] != "ann" || got[1] != "cid" {
+		t.Fatalf("checkouts = %v after bob's return, want [ann cid]", got)
+	}
+
+	w := serve(router, http.MethodPatch, "/return?id=2", "")
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.Quantity != 19 {
+		t.Errorf("quantity = %d, want 19", b.Quantity)
+	}
+	if got := checkoutUsers(t, router, "2"); len(got) != 1 || got[0] != "cid" {
+		t.Fatalf("checkouts = %v after an anonymous return, want the oldest cleared", got)
+	}
+}
+
+func TestReturnBookRejectsOverReturn(t *testing.T) {
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2", ""), http.StatusUnprocessableEntity)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2&user=bob", ""), http.StatusUnprocessableEntity)
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2&user=ann", ""), http.StatusUnprocessableEntity)
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); b.Quantity != 20 {
+		t.Errorf("quantity = %d, want 20", b.Quantity)
+	}
+}
+
+func TestReturnBookAllowOverstock(t *testing.T) {
+	t.Setenv("ALLOW_OVERSTOCK", "true")
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPatch, "/return?id=2", "")
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.Quantity != 21 {
+		t.Errorf("quantity = %d, want 21 after returning a book that was not checked out", b.Quantity)
+	}
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=404", ""), http.StatusNotFound)
+}
+
+func TestRunAutoReturnReturnsOverdueBooks(t *testing.T) {
+	t.Setenv("LOAN_PERIOD_DAYS", "1")
+	router := newTestRouter(t)
+	clk := stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=bob", ""), http.StatusOK)
+	clk.now = clk.now.Add(36 * time.Hour)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=cid", ""), http.StatusOK)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runAutoReturn(ctx, 5*time.Millisecond, 6*time.Hour)
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); len(checkoutUsers(t, router, "1")) > 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("overdue checkout was not auto-returned")
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop after cancellation")
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 2 {
+		t.Errorf("quantity of book 1 = %d, want 2", b.Quantity)
+	}
+	if got := checkoutUsers(t, router, "2"); len(got) != 1 || got[0] != "cid" {
+		t.Errorf("checkouts of book 2 = %v, want only the one that is not overdue", got)
+	}
+}
+
+func TestCheckoutHonorsPerBookLoanDays(t *testing.T) {
+	router := newTestRouter(t)
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	stopClock(t, now)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Encyclopedia","quantity":1,"loan_days":3}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Atlas","loan_days":-1}`), http.StatusBadRequest)
+
+	w := serve(router, http.MethodPatch, "/checkout?id=5&user=ann", "")
+	expectStatus(t, w, http.StatusOK)
+	if got, want := decode[checkoutResponse](t, w).DueAt, now.AddDate(0, 0, 3); !got.Equal(want) {
+		t.Errorf("due_at = %v, want %v", got, want)
+	}
+
+	w = serve(router, http.MethodPatch, "/checkout?id=1&user=ann", "")
+	if got, want := decode[checkoutResponse](t, w).DueAt, now.Add(loanPeriod); !got.Equal(want) {
+		t.Errorf("due_at without loan_days = %v, want %v", got, want)
+	}
+}
+
+func TestCheckoutByTitle(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"goroutines","author":"Ms. Goroutine","quantity":25}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Sold out","quantity":0}`), http.StatusCreated)
+
+	w := serve(router, http.MethodPost, "/checkout/by-title", `{"title":"GOROUTINES","user":"ann"}`)
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[checkoutResponse](t, w).Data; b.ID != "5" || b.Quantity != 24 {
+		t.Errorf("checked out book %s with quantity %d left, want book 5 with 24", b.ID, b.Quantity)
+	}
+	if got := checkoutUsers(t, router, "5"); len(got) != 1 || got[0] != "ann" {
+		t.Errorf("checkouts = %v, want ann's", got)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/checkout/by-title", `{"title":"Sold out"}`), http.StatusUnprocessableEntity)
+	expectStatus(t, serve(router, http.MethodPost, "/checkout/by-title", `{"title":"Missing"}`), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodPost, "/checkout/by-title", `{"user":"ann"}`), http.StatusBadRequest)
+}
+
+func TestCheckoutKeepsReservedCopies(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Popular","quantity":3,"reserved_quantity":4}`), http.StatusBadRequest)
+
+	w := serve(router, http.MethodPost, "/books", `{"id":"5","title":"Popular","quantity":3,"reserved_quantity":2}`)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.AvailableForCheckout != 1 {
+		t.Errorf("available_for_checkout = %d, want 1", b.AvailableForCheckout)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=bob", ""), http.StatusUnprocessableEntity)
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); b.Quantity != 2 || b.AvailableForCheckout != 0 {
+		t.Errorf("quantity %d with %d available for checkout, want the 2 reserved copies kept", b.Quantity, b.AvailableForCheckout)
+	}
+}
+
+func TestGetCheckoutsDueToday(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clk := stopClock(t, start)
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"loan_days":15}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=bob", ""), http.StatusOK)
+
+	due := func(path string) []dueCheckout {
+		t.Helper()
+		w := serve(router, http.MethodGet, path, "")
+		expectStatus(t, w, http.StatusOK)
+		return decode[[]dueCheckout](t, w)
+	}
+
+	clk.now = start.AddDate(0, 0, 14).Add(-6 * time.Hour)
+	if got := due("/checkouts/due-today"); len(got) != 1 || got[0].BookID != "1" || got[0].User != "ann" || got[0].Title != "Golang pointers" {
+		t.Errorf("due today = %+v, want ann's checkout of Golang pointers", got)
+	}
+	if got := due("/checkouts/due-today?date=2024-03-16"); len(got) != 1 || got[0].BookID != "2" || got[0].User != "bob" {
+		t.Errorf("due on 2024-03-16 = %+v, want bob's checkout of book 2", got)
+	}
+	if got := due("/checkouts/due-today?date=2024-03-17"); len(got) != 0 {
+		t.Errorf("due on 2024-03-17 = %+v, want none", got)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/checkouts/due-today?date=03/15/2024", ""), http.StatusBadRequest)
+}
+
+func TestCheckoutStopsAtMinQuantity(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Reference","quantity":3,"min_quantity":1}`), http.StatusCreated)
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=bob", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=cid", ""), http.StatusConflict)
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); b.Quantity != 1 {
+		t.Errorf("quantity = %d, want the floor of 1 kept on the shelf", b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=5&user=ann", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&user=cid", ""), http.StatusOK)
+}
@@ -0,0 +1,31 @@
+package main
+
+import "time"
+
+// clock tells the current time. Everything that depends on the time of day, such as timestamps,
+// due dates, overdue detection, and availability windows, reads it from serverClock rather than
+// calling time.Now, so that it can be pinned to a fixed time. Durations measured for logging,
+// metrics, and rate limiting use the system clock.
+type clock interface {
+	Now() time.Time
+}
+
+// systemClock is the clock that reads the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// fixedClock is a clock that is stopped at a given time, for deterministic tests of time-dependent
+// behavior; advance it by assigning a new time.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+// serverClock is the clock of the server.
+var serverClock clock = systemClock{}
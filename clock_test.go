@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFixedClockTriggersOverdue(t *testing.T) {
+	t.Setenv("LOAN_PERIOD_DAYS", "14")
+	router := newTestRouter(t)
+	clk := stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&user=ann", ""), http.StatusOK)
+
+	due := decode[[]dueCheckout](t, serve(router, http.MethodGet, "/checkouts/due-today?date=2024-03-15", ""))
+	if want := clk.now.AddDate(0, 0, 14); len(due) != 1 || !due[0].DueAt.Equal(want) {
+		t.Fatalf("due checkouts = %+v, want one due at %v", due, want)
+	}
+
+	clk.now = clk.now.AddDate(0, 0, 14)
+	if n := autoReturnOverdue(serverClock.Now(), time.Hour); n != 0 {
+		t.Fatalf("auto-returned %d books on the due date, want 0", n)
+	}
+
+	clk.now = clk.now.Add(2 * time.Hour)
+	if n := autoReturnOverdue(serverClock.Now(), time.Hour); n != 1 {
+		t.Fatalf("auto-returned %d books once overdue, want 1", n)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 2 {
+		t.Errorf("quantity = %d, want 2 after the auto-return", b.Quantity)
+	}
+}
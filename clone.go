@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cloneBook creates a new book from the book with the ID given in the path, for cataloguing
+// similar editions. The clone gets a fresh random ID and the source's title, author, category,
+// tags, cover URL, loan period, and availability window. It starts with no ISBN, since every edition
+// has its own, and a borrow count of 0. It does not track copies, and its quantity is 0 unless 'copy_quantity=true' is given,
+// in which case it gets the source's quantity, reserved quantity, and minimum quantity.
+// It returns the new book with status code 201 (Created), a 404 status code if the source does not exist,
+// or a 409 status code if unique title and author pairs are enforced.
+func cloneBook(c *gin.Context) {
+	copyQuantity, err := strconv.ParseBool(c.DefaultQuery("copy_quantity", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("copy_quantity", "a boolean").Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	source, err := lib.getBookById(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+
+	now := serverClock.Now()
+	clone := book{
+		ID:             newUUID(),
+		Title:          source.Title,
+		Author:         source.Author,
+		Category:       source.Category,
+		Tags:           slices.Clone(source.Tags),
+		CoverURL:       source.CoverURL,
+		LoanDays:       source.LoanDays,
+		AvailableFrom:  source.AvailableFrom,
+		AvailableUntil: source.AvailableUntil,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if !checkTitleAuthorAvailable(c, lib, clone.Title, clone.Author, "") {
+		return
+	}
+	if copyQuantity {
+		clone.Quantity = source.Quantity
+		clone.ReservedQuantity = source.ReservedQuantity
+		clone.MinQuantity = source.MinQuantity
+	}
+
+	lib.books = append(lib.books, clone)
+	markStoreChanged()
+	respondJSON(c, http.StatusCreated, clone)
+}
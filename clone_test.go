@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestCloneBook(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"isbn":"978-0134190440","tags":["concurrency"]}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+
+	w := serve(router, http.MethodPost, "/books/2/clone", "")
+	expectStatus(t, w, http.StatusCreated)
+	clone := decode[book](t, w)
+	if clone.ID == "" || clone.ID == "2" {
+		t.Fatalf("clone ID = %q, want a fresh ID", clone.ID)
+	}
+	if clone.Title != "Goroutines" || clone.Author != "Mr. Goroutine" || !slices.Equal(clone.Tags, []string{"concurrency"}) {
+		t.Errorf("clone = %+v, want the source's title, author, and tags", clone)
+	}
+	if clone.ISBN != "" || clone.Quantity != 0 || clone.BorrowCount != 0 {
+		t.Errorf("clone has ISBN %q, quantity %d, and borrow count %d, want none of the source's", clone.ISBN, clone.Quantity, clone.BorrowCount)
+	}
+	if got := checkoutUsers(t, router, clone.ID); len(got) != 0 {
+		t.Errorf("clone checkouts = %v, want none", got)
+	}
+
+	// The clone is independent of its source.
+	expectStatus(t, serve(router, http.MethodPatch, "/books/"+clone.ID, `{"tags":["second edition"]}`), http.StatusOK)
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); !slices.Equal(b.Tags, []string{"concurrency"}) {
+		t.Errorf("source tags = %v after changing the clone's, want them untouched", b.Tags)
+	}
+
+	w = serve(router, http.MethodPost, "/books/2/clone?copy_quantity=true", "")
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.Quantity != 19 || b.ID == clone.ID {
+		t.Errorf("clone %s has quantity %d, want a new ID and the source's 19", b.ID, b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/books/404/clone", ""), http.StatusNotFound)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setLastModified sets the Last-Modified header of the response to the book's last modification time.
+func setLastModified(c *gin.Context, b *book) {
+	c.Header("Last-Modified", b.UpdatedAt.UTC().Format(http.TimeFormat))
+}
+
+// checkModifiedSince evaluates the request's If-Modified-Since header against the book's
+// last modification time. If the book has not been modified since the given time, it responds
+// with status code 304 (Not Modified) and returns false. Requests without the header, or with
+// a date that cannot be parsed, always pass.
+func checkModifiedSince(c *gin.Context, b *book) bool {
+	header := c.GetHeader("If-Modified-Since")
+	if header == "" {
+		return true
+	}
+
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return true
+	}
+
+	// HTTP dates only have a resolution of one second.
+	if !b.UpdatedAt.Truncate(time.Second).After(since) {
+		c.Status(http.StatusNotModified)
+		return false
+	}
+	return true
+}
+
+// checkUnmodifiedSince evaluates the request's If-Unmodified-Since header against the book's
+// last modification time. If the book was modified after the given time, it responds with
+// status code 412 (Precondition Failed) and returns false. Requests without the header, or
+// with a date that cannot be parsed, always pass.
+func checkUnmodifiedSince(c *gin.Context, b *book) bool {
+	header := c.GetHeader("If-Unmodified-Since")
+	if header == "" {
+		return true
+	}
+
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return true
+	}
+
+	// HTTP dates only have a resolution of one second.
+	if b.UpdatedAt.Truncate(time.Second).After(since) {
+		updatedAt := b.UpdatedAt
+		respondErrorDetails(c, http.StatusPreconditionFailed, "book has been modified since "+header, errorDetails{UpdatedAt: &updatedAt})
+		return false
+	}
+	return true
+}
+
+// etagMatches reports whether the request's If-None-Match header lists etag, or is "*".
+// Validators are compared weakly, as required for If-None-Match: a weak ETag matches its strong counterpart.
+func etagMatches(c *gin.Context, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStaleUpdateFailsPrecondition(t *testing.T) {
+	router := newTestRouter(t)
+	clk := stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"quantity":21}`), http.StatusOK)
+
+	read := serve(router, http.MethodGet, "/books/2", "")
+	expectStatus(t, read, http.StatusOK)
+	lastModified := read.Header().Get("Last-Modified")
+
+	clk.now = clk.now.Add(time.Minute)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"quantity":22}`, "If-Unmodified-Since", lastModified), http.StatusOK)
+
+	w := serve(router, http.MethodPatch, "/books/2", `{"quantity":5}`, "If-Unmodified-Since", lastModified)
+	expectStatus(t, w, http.StatusPreconditionFailed)
+	if got := decode[errorResponse](t, w).UpdatedAt; got == nil || !got.Equal(clk.now) {
+		t.Errorf("updated_at = %v, want %v", got, clk.now)
+	}
+	w = serve(router, http.MethodPut, "/books/2", `{"id":"2","title":"Goroutines","author":"Mr. Goroutine","quantity":5}`, "If-Unmodified-Since", lastModified)
+	expectStatus(t, w, http.StatusPreconditionFailed)
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); b.Quantity != 22 {
+		t.Errorf("quantity = %d, want the other client's 22", b.Quantity)
+	}
+}
+
+func TestBookByIdHonorsIfModifiedSince(t *testing.T) {
+	router := newTestRouter(t)
+	clk := stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"quantity":21}`), http.StatusOK)
+
+	w := serve(router, http.MethodGet, "/books/2", "")
+	expectStatus(t, w, http.StatusOK)
+	if got, want := w.Header().Get("Last-Modified"), "Fri, 01 Mar 2024 12:00:00 GMT"; got != want {
+		t.Errorf("Last-Modified = %q, want %q", got, want)
+	}
+
+	w = serve(router, http.MethodGet, "/books/2", "", "If-Modified-Since", "Fri, 01 Mar 2024 12:30:00 GMT")
+	expectStatus(t, w, http.StatusNotModified)
+	if w.Body.Len() != 0 {
+		t.Errorf("304 response has a body: %s", w.Body.String())
+	}
+
+	clk.now = clk.now.Add(time.Hour)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"quantity":22}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodGet, "/books/2", "", "If-Modified-Since", "Fri, 01 Mar 2024 12:30:00 GMT"), http.StatusOK)
+}
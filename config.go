@@ -0,0 +1,820 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listenAddr is the address the HTTP server listens on.
+const listenAddr = "localhost:3001"
+
+// redacted replaces the value of secrets in the output of getConfig.
+const redacted = "[REDACTED]"
+
+// shutdownTimeout is how long a graceful shutdown waits for in-flight requests to complete
+// before the remaining connections are closed forcibly.
+// It is configured with the SHUTDOWN_TIMEOUT environment variable, e.g. "30s".
+var shutdownTimeout time.Duration
+
+// enableH2C makes the server accept HTTP/2 without TLS (h2c) in addition to HTTP/1.1.
+// It is configured with the ENABLE_H2C environment variable.
+var enableH2C bool
+
+// slowRequestThreshold is the duration above which the request logger flags a request as slow.
+// It is configured with the SLOW_REQUEST_MS environment variable; a value of 0 disables the warning.
+var slowRequestThreshold time.Duration
+
+// logSampleRate is the fraction of successful (2xx) requests that the request logger logs, between 0 and 1.
+// Failed and slow requests are always logged. It is configured with the LOG_SAMPLE_RATE environment variable.
+var logSampleRate float64
+
+// corsAllowedOrigins lists the origins allowed to make cross-origin requests.
+// It is configured with the comma separated CORS_ALLOWED_ORIGINS environment variable; "*" allows any origin.
+var corsAllowedOrigins []string
+
+// corsMaxAge is how long, in seconds, browsers may cache the result of a CORS preflight request.
+// It is configured with the CORS_MAX_AGE environment variable.
+var corsMaxAge int
+
+// exportMaxAge is how long, in seconds, clients and CDNs may cache an export before revalidating it.
+// It is configured with the EXPORT_MAX_AGE environment variable; 0 makes them revalidate every time.
+var exportMaxAge int
+
+// rateLimits limits the requests of every client IP per route group, reads or writes.
+// It is configured with the RATE_LIMITS environment variable, e.g. "reads=100/s,writes=10/s";
+// route groups without a limit are not limited.
+var rateLimits map[string]rateLimit
+
+// maxConcurrent is the maximum number of requests handled at the same time; 0 means unlimited.
+// It is configured with the MAX_CONCURRENT environment variable.
+var maxConcurrent int
+
+// enableChaos enables fault injection for resilience testing of clients. No fault is ever
+// injected unless it is set, whatever else is configured.
+// It is configured with the ENABLE_CHAOS environment variable.
+var enableChaos bool
+
+// chaosLatency is the delay injected into every request when enableChaos is set; 0 injects none.
+// It is configured with the CHAOS_LATENCY_MS environment variable.
+var chaosLatency time.Duration
+
+// chaosLatencyRandom makes the injected delay a random duration up to chaosLatency.
+// It is configured with the CHAOS_LATENCY_RANDOM environment variable.
+var chaosLatencyRandom bool
+
+// maxConnPerIP is the maximum number of requests handled at the same time for a single peer IP
+// address; 0 means unlimited. It is configured with the MAX_CONN_PER_IP environment variable.
+var maxConnPerIP int
+
+// trustedProxies lists the addresses of proxies in front of the server, which are exempt from maxConnPerIP
+// and the only peers whose X-Forwarded-For header is believed when rate limiting by client IP.
+// It is configured with the comma separated TRUSTED_PROXIES environment variable of IP addresses and CIDR prefixes.
+var trustedProxies []netip.Prefix
+
+// retryAfterJitter is the largest random delay added to Retry-After headers, so that rejected
+// clients do not all retry at once. It is configured with the RETRY_AFTER_JITTER environment variable.
+var retryAfterJitter time.Duration
+
+// readOnly rejects every request that could modify the store, for read-only mirrors.
+// It is configured with the READ_ONLY environment variable.
+var readOnly bool
+
+// printRoutes prints a banner listing every route and the effective configuration on startup.
+// It is configured with the PRINT_ROUTES environment variable.
+var printRoutes bool
+
+// strictStartup makes the server refuse to start when the store integrity check finds violations,
+// rather than only logging them. It is configured with the STRICT_STARTUP environment variable.
+var strictStartup bool
+
+// recoverPanics makes the server recover from panics in handlers and respond with 500 instead of crashing.
+// It is configured with the RECOVER_PANICS environment variable.
+var recoverPanics bool
+
+// alertWebhook is the URL that panic alerts are posted to; when empty, no alerts are sent.
+// It is configured with the ALERT_WEBHOOK environment variable.
+var alertWebhook string
+
+// errorFormat selects how error responses are rendered: errorFormatSimple or errorFormatProblem.
+// It is configured with the ERROR_FORMAT environment variable.
+var errorFormat string
+
+// responseCharset is the charset parameter of the Content-Type of JSON responses.
+// It is configured with the RESPONSE_CHARSET environment variable, as "utf-8" or "UTF-8"; "none" omits it.
+var responseCharset string
+
+// jsonFieldStyle is the naming style of the fields of JSON responses, fieldStyleSnake or fieldStyleCamel.
+// It is configured with the JSON_FIELD_STYLE environment variable.
+var jsonFieldStyle string
+
+// emptyAs204 makes GET /books respond with an empty 204 (No Content) instead of an empty list when
+// no book matches, for clients that cannot handle empty arrays. It is configured with the EMPTY_AS_204
+// environment variable.
+var emptyAs204 bool
+
+// prettyJSON indents JSON responses, unless a request asks otherwise with an X-Pretty-Print header.
+// It is configured with the PRETTY_JSON environment variable.
+var prettyJSON bool
+
+// adminAPIKey is the key clients must send in the X-API-Key header to use admin-only routes.
+// It is configured with the ADMIN_API_KEY environment variable; when unset, admin-only routes are disabled.
+var adminAPIKey string
+
+// apiKeysFile is the path of the JSON file apiKeys are read from, configured with the API_KEYS_FILE environment variable.
+var apiKeysFile string
+
+// apiKeys maps each scoped API key to the scopes it grants, such as scopeRead. Once any are
+// configured, every request needs a key; see requireScope. They are read from the JSON file named
+// by the API_KEYS_FILE environment variable.
+var apiKeys map[string][]string
+
+// tenantAllowlist lists the tenant IDs accepted in the X-Tenant-ID header.
+// It is configured with the comma separated TENANT_ALLOWLIST environment variable;
+// when empty, any tenant is accepted. The default tenant is always accepted.
+var tenantAllowlist []string
+
+// uniqueISBN rejects creating or updating a book with an ISBN that is already used by another book.
+// It is configured with the UNIQUE_ISBN environment variable.
+var uniqueISBN bool
+
+// uniqueTitleAuthor rejects creating or updating a book with the same title and author as another book.
+// It is configured with the UNIQUE_TITLE_AUTHOR environment variable.
+var uniqueTitleAuthor bool
+
+// importMergeDuplicates merges entries of an import that share an ISBN into a single book by summing
+// their quantities, instead of rejecting the import. It is configured with the IMPORT_MERGE_DUPLICATES environment variable.
+var importMergeDuplicates bool
+
+// defaultSort is the order of GET /books when the request has no 'sort' query parameter.
+// It is configured with the DEFAULT_SORT environment variable, e.g. "title:asc"; when unset,
+// books are listed in insertion order.
+var defaultSort sortSpec
+
+// putUpsert makes PUT /books/:id create a book that does not exist yet rather than responding with 404.
+// It is configured with the PUT_UPSERT environment variable.
+var putUpsert bool
+
+// maxSearchResults is the maximum number of books returned by POST /books/search; 0 means unlimited.
+// It is configured with the MAX_SEARCH_RESULTS environment variable.
+var maxSearchResults int
+
+// strictJSON rejects request bodies of book creates and updates that contain unknown fields.
+// It is configured with the STRICT_JSON environment variable.
+var strictJSON bool
+
+// allowOverstock lets PATCH /return increment the quantity of a book that has no outstanding
+// checkout, so that stock can grow beyond what was ever checked out. When false, such returns are
+// rejected. It is configured with the ALLOW_OVERSTOCK environment variable.
+var allowOverstock bool
+
+// defaultAuthor is the author of a book created without an author, e.g. "Unknown".
+// It is configured with the DEFAULT_AUTHOR environment variable; when unset, such books have an empty author.
+var defaultAuthor string
+
+// defaultQuantity is the quantity of a book created without a quantity.
+// It is configured with the DEFAULT_QUANTITY environment variable.
+var defaultQuantity quantity
+
+// maxBookQuantity is the largest quantity a single book may be given by creating, updating, or
+// restocking it, to catch data-entry errors. It is configured with the MAX_BOOK_QUANTITY
+// environment variable; 0 means unlimited.
+var maxBookQuantity quantity
+
+// loanPeriod is how long a book may be kept after checkout before it is due back.
+// It is configured in days with the LOAN_PERIOD_DAYS environment variable.
+var loanPeriod time.Duration
+
+// autoReturnEnabled turns on the background worker that automatically returns overdue books.
+// It is configured with the AUTO_RETURN_ENABLED environment variable.
+var autoReturnEnabled bool
+
+// autoReturnAfter is the grace period after the due date before a checkout is automatically returned.
+// It is configured with the AUTO_RETURN_AFTER environment variable, e.g. "72h".
+var autoReturnAfter time.Duration
+
+// autoReturnInterval is how often the auto-return worker scans for overdue checkouts.
+// It is configured with the AUTO_RETURN_INTERVAL environment variable, e.g. "1h".
+var autoReturnInterval time.Duration
+
+// otlpEndpoint is the base URL of the OTLP/HTTP collector traces are exported to, e.g. "http://collector:4318".
+// It is configured with the standard OTEL_EXPORTER_OTLP_ENDPOINT setting; when unset, tracing is a no-op.
+var otlpEndpoint string
+
+// configFile is the path of the configuration file the configuration was read from, if any; see loadConfigFile.
+// It is configured with the CONFIG_FILE environment variable.
+var configFile string
+
+// featureFlagsFile is the path of the JSON file the feature flags are read from; see loadFeatures.
+// It is configured with the FEATURE_FLAGS_FILE environment variable.
+var featureFlagsFile string
+
+// effectiveConfig is the runtime configuration reported by getConfig.
+// Secrets are redacted.
+type effectiveConfig struct {
+	ListenAddr            string            `json:"listen_addr"`
+	ShutdownTimeout       string            `json:"shutdown_timeout"`
+	EnableH2C             bool              `json:"enable_h2c"`
+	MaxConcurrent         int               `json:"max_concurrent"`
+	MaxConnPerIP          int               `json:"max_conn_per_ip"`
+	TrustedProxies        []string          `json:"trusted_proxies"`
+	EnableChaos           bool              `json:"enable_chaos"`
+	ChaosLatency          string            `json:"chaos_latency"`
+	ChaosLatencyRandom    bool              `json:"chaos_latency_random"`
+	RetryAfterJitter      string            `json:"retry_after_jitter"`
+	SlowRequestThreshold  string            `json:"slow_request_threshold"`
+	LogSampleRate         float64           `json:"log_sample_rate"`
+	CORSAllowedOrigins    []string          `json:"cors_allowed_origins"`
+	CORSMaxAge            int               `json:"cors_max_age"`
+	ExportMaxAge          int               `json:"export_max_age"`
+	RateLimits            map[string]string `json:"rate_limits"`
+	ReadOnly              bool              `json:"read_only"`
+	PrintRoutes           bool              `json:"print_routes"`
+	StrictStartup         bool              `json:"strict_startup"`
+	RecoverPanics         bool              `json:"recover_panics"`
+	AlertWebhook          string            `json:"alert_webhook"`
+	ErrorFormat           string            `json:"error_format"`
+	ResponseCharset       string            `json:"response_charset"`
+	JSONFieldStyle        string            `json:"json_field_style"`
+	PrettyJSON            bool              `json:"pretty_json"`
+	EmptyAs204            bool              `json:"empty_as_204"`
+	AdminAPIKey           string            `json:"admin_api_key"`
+	APIKeys               int               `json:"api_keys"`
+	APIKeysFile           string            `json:"api_keys_file"`
+	TenantAllowlist       []string          `json:"tenant_allowlist"`
+	UniqueISBN            bool              `json:"unique_isbn"`
+	UniqueTitleAuthor     bool              `json:"unique_title_author"`
+	ImportMergeDuplicates bool              `json:"import_merge_duplicates"`
+	DefaultSort           string            `json:"default_sort"`
+	PutUpsert             bool              `json:"put_upsert"`
+	MaxSearchResults      int               `json:"max_search_results"`
+	StrictJSON            bool              `json:"strict_json"`
+	DefaultAuthor         string            `json:"default_author"`
+	DefaultQuantity       int               `json:"default_quantity"`
+	MaxBookQuantity       int               `json:"max_book_quantity"`
+	AllowOverstock        bool              `json:"allow_overstock"`
+	LoanPeriod            string            `json:"loan_period"`
+	AutoReturnEnabled     bool              `json:"auto_return_enabled"`
+	AutoReturnAfter       string            `json:"auto_return_after"`
+	AutoReturnInterval    string            `json:"auto_return_interval"`
+	OTLPEndpoint          string            `json:"otlp_endpoint"`
+	ConfigFile            string            `json:"config_file"`
+	FeatureFlagsFile      string            `json:"feature_flags_file"`
+	Features              map[string]bool   `json:"features"`
+}
+
+// currentConfig returns the effective runtime configuration with secrets redacted.
+func currentConfig() effectiveConfig {
+	charset := responseCharset
+	if charset == "" {
+		charset = "none"
+	}
+
+	return effectiveConfig{
+		ListenAddr:            listenAddr,
+		ShutdownTimeout:       shutdownTimeout.String(),
+		EnableH2C:             enableH2C,
+		MaxConcurrent:         maxConcurrent,
+		MaxConnPerIP:          maxConnPerIP,
+		TrustedProxies:        prefixStrings(trustedProxies),
+		EnableChaos:           enableChaos,
+		ChaosLatency:          chaosLatency.String(),
+		ChaosLatencyRandom:    chaosLatencyRandom,
+		RetryAfterJitter:      retryAfterJitter.String(),
+		SlowRequestThreshold:  slowRequestThreshold.String(),
+		LogSampleRate:         logSampleRate,
+		CORSAllowedOrigins:    corsAllowedOrigins,
+		CORSMaxAge:            corsMaxAge,
+		ExportMaxAge:          exportMaxAge,
+		RateLimits:            rateLimitStrings(),
+		ReadOnly:              readOnly,
+		PrintRoutes:           printRoutes,
+		StrictStartup:         strictStartup,
+		RecoverPanics:         recoverPanics,
+		AlertWebhook:          redact(alertWebhook),
+		ErrorFormat:           errorFormat,
+		ResponseCharset:       charset,
+		JSONFieldStyle:        jsonFieldStyle,
+		PrettyJSON:            prettyJSON,
+		EmptyAs204:            emptyAs204,
+		AdminAPIKey:           redact(adminAPIKey),
+		APIKeys:               len(apiKeys),
+		APIKeysFile:           apiKeysFile,
+		TenantAllowlist:       tenantAllowlist,
+		UniqueISBN:            uniqueISBN,
+		UniqueTitleAuthor:     uniqueTitleAuthor,
+		ImportMergeDuplicates: importMergeDuplicates,
+		DefaultSort:           defaultSort.String(),
+		PutUpsert:             putUpsert,
+		MaxSearchResults:      maxSearchResults,
+		StrictJSON:            strictJSON,
+		DefaultAuthor:         defaultAuthor,
+		DefaultQuantity:       int(defaultQuantity),
+		MaxBookQuantity:       int(maxBookQuantity),
+		AllowOverstock:        allowOverstock,
+		LoanPeriod:            loanPeriod.String(),
+		AutoReturnEnabled:     autoReturnEnabled,
+		AutoReturnAfter:       autoReturnAfter.String(),
+		AutoReturnInterval:    autoReturnInterval.String(),
+		OTLPEndpoint:          otlpEndpoint,
+		ConfigFile:            configFile,
+		FeatureFlagsFile:      featureFlagsFile,
+		Features:              featureStates(),
+	}
+}
+
+// rateLimitStrings returns the configured rate limits in the format they are configured in.
+func rateLimitStrings() map[string]string {
+	limits := make(map[string]string, len(rateLimits))
+	for group, limit := range rateLimits {
+		limits[group] = limit.String()
+	}
+	return limits
+}
+
+// prefixStrings returns the given prefixes in CIDR notation.
+func prefixStrings(prefixes []netip.Prefix) []string {
+	s := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		s = append(s, p.String())
+	}
+	return s
+}
+
+// redact hides the value of a secret, keeping only whether it is set.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// Config holds every configuration setting of the server. Each field configures the package
+// variable of the same name above; loadConfig reads a Config with readConfig and applies it to them.
+type Config struct {
+	ShutdownTimeout       time.Duration
+	EnableH2C             bool
+	SlowRequestThreshold  time.Duration
+	LogSampleRate         float64
+	CORSAllowedOrigins    []string
+	CORSMaxAge            int
+	ExportMaxAge          int
+	RateLimits            map[string]rateLimit
+	MaxConcurrent         int
+	MaxConnPerIP          int
+	TrustedProxies        []netip.Prefix
+	EnableChaos           bool
+	ChaosLatency          time.Duration
+	ChaosLatencyRandom    bool
+	RetryAfterJitter      time.Duration
+	ReadOnly              bool
+	PrintRoutes           bool
+	StrictStartup         bool
+	RecoverPanics         bool
+	AlertWebhook          string
+	ErrorFormat           string
+	ResponseCharset       string
+	JSONFieldStyle        string
+	PrettyJSON            bool
+	EmptyAs204            bool
+	AdminAPIKey           string
+	APIKeysFile           string
+	APIKeys               map[string][]string
+	TenantAllowlist       []string
+	UniqueISBN            bool
+	UniqueTitleAuthor     bool
+	ImportMergeDuplicates bool
+	DefaultSort           sortSpec
+	PutUpsert             bool
+	MaxSearchResults      int
+	StrictJSON            bool
+	AllowOverstock        bool
+	DefaultAuthor         string
+	DefaultQuantity       int
+	MaxBookQuantity       int
+	LoanPeriod            time.Duration
+	AutoReturnEnabled     bool
+	AutoReturnAfter       time.Duration
+	AutoReturnInterval    time.Duration
+	OTLPEndpoint          string
+	ConfigFile            string
+	FeatureFlagsFile      string
+	// FeatureOverrides holds the FEATURE_<NAME> settings, keyed by lowercase flag name.
+	FeatureOverrides map[string]bool
+	// Features is the resulting state of the feature flags, as described by loadFeatures.
+	Features map[string]bool
+}
+
+// defaultConfig returns the configuration the server runs with when nothing is configured.
+func defaultConfig() Config {
+	return Config{
+		ShutdownTimeout:      10 * time.Second,
+		SlowRequestThreshold: time.Second,
+		LogSampleRate:        1,
+		CORSAllowedOrigins:   []string{"*"},
+		CORSMaxAge:           600,
+		ExportMaxAge:         60,
+		RateLimits:           map[string]rateLimit{},
+		MaxConcurrent:        256,
+		RecoverPanics:        true,
+		ErrorFormat:          errorFormatSimple,
+		ResponseCharset:      "utf-8",
+		JSONFieldStyle:       fieldStyleSnake,
+		PrettyJSON:           true,
+		PutUpsert:            true,
+		MaxSearchResults:     1000,
+		DefaultQuantity:      1,
+		LoanPeriod:           14 * 24 * time.Hour,
+		AutoReturnAfter:      7 * 24 * time.Hour,
+		AutoReturnInterval:   time.Hour,
+		FeatureOverrides:     map[string]bool{},
+	}
+}
+
+// loadConfig reads the server configuration with readConfig and applies it to the package variables.
+func loadConfig() error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+	cfg.apply()
+	return nil
+}
+
+// readConfig reads the server configuration in three layers: the defaults of defaultConfig, then
+// the configuration file named by the CONFIG_FILE environment variable, if any, as described by
+// loadConfigFile, and then the environment variables. Each layer overrides the settings it sets.
+// It returns an error if a setting has an invalid value.
+func readConfig() (Config, error) {
+	cfg := defaultConfig()
+
+	cfg.ConfigFile = os.Getenv("CONFIG_FILE")
+	if cfg.ConfigFile != "" {
+		settings, err := loadConfigFile(cfg.ConfigFile)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := cfg.set(configSource{name: "CONFIG_FILE", values: settings}); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.set(environmentSource()); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, err
+	}
+
+	var err error
+	if cfg.APIKeys, err = loadAPIKeys(cfg.APIKeysFile); err != nil {
+		return Config{}, err
+	}
+	if cfg.Features, err = loadFeatures(cfg.FeatureFlagsFile, cfg.FeatureOverrides); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// set overrides the settings of cfg with those that src sets.
+func (cfg *Config) set(src configSource) error {
+	src.duration("SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout)
+	src.bool("ENABLE_H2C", &cfg.EnableH2C)
+	src.milliseconds("SLOW_REQUEST_MS", &cfg.SlowRequestThreshold)
+	src.float("LOG_SAMPLE_RATE", &cfg.LogSampleRate)
+	src.list("CORS_ALLOWED_ORIGINS", &cfg.CORSAllowedOrigins)
+	src.int("CORS_MAX_AGE", &cfg.CORSMaxAge)
+	src.int("EXPORT_MAX_AGE", &cfg.ExportMaxAge)
+	src.parse("RATE_LIMITS", func(v string) (err error) {
+		cfg.RateLimits, err = parseRateLimits(v)
+		return err
+	})
+	src.int("MAX_CONCURRENT", &cfg.MaxConcurrent)
+	src.int("MAX_CONN_PER_IP", &cfg.MaxConnPerIP)
+	src.parse("TRUSTED_PROXIES", func(v string) (err error) {
+		cfg.TrustedProxies, err = parseTrustedProxies(splitList(v))
+		return err
+	})
+	src.bool("ENABLE_CHAOS", &cfg.EnableChaos)
+	src.milliseconds("CHAOS_LATENCY_MS", &cfg.ChaosLatency)
+	src.bool("CHAOS_LATENCY_RANDOM", &cfg.ChaosLatencyRandom)
+	src.duration("RETRY_AFTER_JITTER", &cfg.RetryAfterJitter)
+	src.bool("READ_ONLY", &cfg.ReadOnly)
+	src.bool("PRINT_ROUTES", &cfg.PrintRoutes)
+	src.bool("STRICT_STARTUP", &cfg.StrictStartup)
+	src.bool("RECOVER_PANICS", &cfg.RecoverPanics)
+	src.string("ALERT_WEBHOOK", &cfg.AlertWebhook)
+	src.string("ERROR_FORMAT", &cfg.ErrorFormat)
+	src.string("RESPONSE_CHARSET", &cfg.ResponseCharset)
+	src.string("JSON_FIELD_STYLE", &cfg.JSONFieldStyle)
+	src.bool("PRETTY_JSON", &cfg.PrettyJSON)
+	src.bool("EMPTY_AS_204", &cfg.EmptyAs204)
+	src.string("ADMIN_API_KEY", &cfg.AdminAPIKey)
+	src.string("API_KEYS_FILE", &cfg.APIKeysFile)
+	src.list("TENANT_ALLOWLIST", &cfg.TenantAllowlist)
+	src.bool("UNIQUE_ISBN", &cfg.UniqueISBN)
+	src.bool("UNIQUE_TITLE_AUTHOR", &cfg.UniqueTitleAuthor)
+	src.bool("IMPORT_MERGE_DUPLICATES", &cfg.ImportMergeDuplicates)
+	src.parse("DEFAULT_SORT", func(v string) (err error) {
+		cfg.DefaultSort, err = parseSort(v)
+		return err
+	})
+	src.bool("PUT_UPSERT", &cfg.PutUpsert)
+	src.int("MAX_SEARCH_RESULTS", &cfg.MaxSearchResults)
+	src.bool("STRICT_JSON", &cfg.StrictJSON)
+	src.bool("ALLOW_OVERSTOCK", &cfg.AllowOverstock)
+	src.string("DEFAULT_AUTHOR", &cfg.DefaultAuthor)
+	src.int("DEFAULT_QUANTITY", &cfg.DefaultQuantity)
+	src.int("MAX_BOOK_QUANTITY", &cfg.MaxBookQuantity)
+	src.days("LOAN_PERIOD_DAYS", &cfg.LoanPeriod)
+	src.bool("AUTO_RETURN_ENABLED", &cfg.AutoReturnEnabled)
+	src.duration("AUTO_RETURN_AFTER", &cfg.AutoReturnAfter)
+	src.duration("AUTO_RETURN_INTERVAL", &cfg.AutoReturnInterval)
+	src.string("OTEL_EXPORTER_OTLP_ENDPOINT", &cfg.OTLPEndpoint)
+	src.string("FEATURE_FLAGS_FILE", &cfg.FeatureFlagsFile)
+
+	for _, key := range src.keys() {
+		name, ok := strings.CutPrefix(key, featureEnvPrefix)
+		if !ok || name == "" || key == "FEATURE_FLAGS_FILE" {
+			continue
+		}
+		var enabled bool
+		if src.bool(key, &enabled) {
+			cfg.FeatureOverrides[strings.ToLower(name)] = enabled
+		}
+	}
+
+	return src.err
+}
+
+// validate returns an error if a setting of cfg is out of range.
+func (cfg *Config) validate() error {
+	if cfg.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT must be positive, got %s", cfg.ShutdownTimeout)
+	}
+	if cfg.SlowRequestThreshold < 0 {
+		return fmt.Errorf("SLOW_REQUEST_MS must not be negative, got %d", cfg.SlowRequestThreshold.Milliseconds())
+	}
+	if cfg.LogSampleRate < 0 || cfg.LogSampleRate > 1 {
+		return fmt.Errorf("LOG_SAMPLE_RATE must be between 0 and 1, got %g", cfg.LogSampleRate)
+	}
+	if cfg.CORSMaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative, got %d", cfg.CORSMaxAge)
+	}
+	if cfg.ExportMaxAge < 0 {
+		return fmt.Errorf("EXPORT_MAX_AGE must not be negative, got %d", cfg.ExportMaxAge)
+	}
+	if cfg.MaxConcurrent < 0 {
+		return fmt.Errorf("MAX_CONCURRENT must not be negative, got %d", cfg.MaxConcurrent)
+	}
+	if cfg.MaxConnPerIP < 0 {
+		return fmt.Errorf("MAX_CONN_PER_IP must not be negative, got %d", cfg.MaxConnPerIP)
+	}
+	if cfg.EnableChaos && cfg.ChaosLatency < 0 {
+		return fmt.Errorf("CHAOS_LATENCY_MS must not be negative, got %d", cfg.ChaosLatency.Milliseconds())
+	}
+	if cfg.RetryAfterJitter < 0 {
+		return fmt.Errorf("RETRY_AFTER_JITTER must not be negative, got %s", cfg.RetryAfterJitter)
+	}
+	if cfg.AlertWebhook != "" {
+		if u, err := url.Parse(cfg.AlertWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("ALERT_WEBHOOK must be an absolute http or https URL")
+		}
+	}
+	if cfg.OTLPEndpoint != "" {
+		if u, err := url.Parse(cfg.OTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be an absolute http or https URL")
+		}
+	}
+	if cfg.ErrorFormat != errorFormatSimple && cfg.ErrorFormat != errorFormatProblem {
+		return fmt.Errorf("ERROR_FORMAT must be %q or %q, got %q", errorFormatSimple, errorFormatProblem, cfg.ErrorFormat)
+	}
+	if !slices.Contains([]string{"utf-8", "UTF-8", "none"}, cfg.ResponseCharset) {
+		return fmt.Errorf("RESPONSE_CHARSET must be %q, %q, or %q, got %q", "utf-8", "UTF-8", "none", cfg.ResponseCharset)
+	}
+	if cfg.JSONFieldStyle != fieldStyleSnake && cfg.JSONFieldStyle != fieldStyleCamel {
+		return fmt.Errorf("JSON_FIELD_STYLE must be %q or %q, got %q", fieldStyleSnake, fieldStyleCamel, cfg.JSONFieldStyle)
+	}
+	if cfg.MaxSearchResults < 0 {
+		return fmt.Errorf("MAX_SEARCH_RESULTS must not be negative, got %d", cfg.MaxSearchResults)
+	}
+	if cfg.DefaultQuantity < 0 {
+		return fmt.Errorf("DEFAULT_QUANTITY must not be negative, got %d", cfg.DefaultQuantity)
+	}
+	if cfg.MaxBookQuantity < 0 {
+		return fmt.Errorf("MAX_BOOK_QUANTITY must not be negative, got %d", cfg.MaxBookQuantity)
+	}
+	if cfg.LoanPeriod <= 0 {
+		return fmt.Errorf("LOAN_PERIOD_DAYS must be positive, got %d", cfg.LoanPeriod/(24*time.Hour))
+	}
+	if cfg.AutoReturnAfter < 0 {
+		return fmt.Errorf("AUTO_RETURN_AFTER must not be negative, got %s", cfg.AutoReturnAfter)
+	}
+	if cfg.AutoReturnInterval <= 0 {
+		return fmt.Errorf("AUTO_RETURN_INTERVAL must be positive, got %s", cfg.AutoReturnInterval)
+	}
+	return nil
+}
+
+// apply sets the package variables to the settings of cfg. The CHAOS_* settings only take effect
+// if chaos is enabled, and a RESPONSE_CHARSET of "none" omits the charset.
+func (cfg *Config) apply() {
+	shutdownTimeout = cfg.ShutdownTimeout
+	enableH2C = cfg.EnableH2C
+	slowRequestThreshold = cfg.SlowRequestThreshold
+	logSampleRate = cfg.LogSampleRate
+	corsAllowedOrigins = cfg.CORSAllowedOrigins
+	corsMaxAge = cfg.CORSMaxAge
+	exportMaxAge = cfg.ExportMaxAge
+	rateLimits = cfg.RateLimits
+	maxConcurrent = cfg.MaxConcurrent
+	maxConnPerIP = cfg.MaxConnPerIP
+	trustedProxies = cfg.TrustedProxies
+	enableChaos = cfg.EnableChaos
+	chaosLatency, chaosLatencyRandom = 0, false
+	if cfg.EnableChaos {
+		chaosLatency, chaosLatencyRandom = cfg.ChaosLatency, cfg.ChaosLatencyRandom
+	}
+	retryAfterJitter = cfg.RetryAfterJitter
+	readOnly = cfg.ReadOnly
+	printRoutes = cfg.PrintRoutes
+	strictStartup = cfg.StrictStartup
+	recoverPanics = cfg.RecoverPanics
+	alertWebhook = cfg.AlertWebhook
+	errorFormat = cfg.ErrorFormat
+	responseCharset = cfg.ResponseCharset
+	if responseCharset == "none" {
+		responseCharset = ""
+	}
+	jsonFieldStyle = cfg.JSONFieldStyle
+	prettyJSON = cfg.PrettyJSON
+	emptyAs204 = cfg.EmptyAs204
+	adminAPIKey = cfg.AdminAPIKey
+	apiKeysFile = cfg.APIKeysFile
+	apiKeys = cfg.APIKeys
+	tenantAllowlist = cfg.TenantAllowlist
+	uniqueISBN = cfg.UniqueISBN
+	uniqueTitleAuthor = cfg.UniqueTitleAuthor
+	importMergeDuplicates = cfg.ImportMergeDuplicates
+	defaultSort = cfg.DefaultSort
+	putUpsert = cfg.PutUpsert
+	maxSearchResults = cfg.MaxSearchResults
+	strictJSON = cfg.StrictJSON
+	allowOverstock = cfg.AllowOverstock
+	defaultAuthor = cfg.DefaultAuthor
+	defaultQuantity = quantity(cfg.DefaultQuantity)
+	maxBookQuantity = quantity(cfg.MaxBookQuantity)
+	loanPeriod = cfg.LoanPeriod
+	autoReturnEnabled = cfg.AutoReturnEnabled
+	autoReturnAfter = cfg.AutoReturnAfter
+	autoReturnInterval = cfg.AutoReturnInterval
+	otlpEndpoint = cfg.OTLPEndpoint
+	configFile = cfg.ConfigFile
+	featureFlagsFile = cfg.FeatureFlagsFile
+	features = cfg.Features
+}
+
+// configSource is a layer of configuration settings keyed by the names of their environment
+// variables, such as the environment itself or the configuration file. Settings set to an empty
+// value count as unset.
+// Its methods parse the value of a setting into a destination, leaving the destination untouched if
+// the source does not set it. The first value that fails to parse is recorded in err, and every
+// setting after it is ignored.
+type configSource struct {
+	// name describes the source in error messages; it is empty for the environment.
+	name   string
+	values map[string]string
+	err    error
+}
+
+// environmentSource returns the configuration settings of the process environment.
+func environmentSource() configSource {
+	values := map[string]string{}
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			values[key] = value
+		}
+	}
+	return configSource{values: values}
+}
+
+// keys returns the names of all settings of the source, sorted.
+func (s *configSource) keys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parse calls fn with the value of the setting named by key, if the source sets it, and records the
+// error fn returns. It reports whether fn was called and succeeded.
+func (s *configSource) parse(key string, fn func(v string) error) bool {
+	v := s.values[key]
+	if s.err != nil || v == "" {
+		return false
+	}
+
+	if err := fn(v); err != nil {
+		where := key
+		if s.name != "" {
+			where += " in " + s.name
+		}
+		s.err = fmt.Errorf("invalid value for %s: %w", where, err)
+		return false
+	}
+	return true
+}
+
+// string reads the setting named by key into dst.
+func (s *configSource) string(key string, dst *string) {
+	s.parse(key, func(v string) error {
+		*dst = v
+		return nil
+	})
+}
+
+// int reads the integer setting named by key into dst.
+func (s *configSource) int(key string, dst *int) {
+	s.parse(key, func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+		*dst = n
+		return nil
+	})
+}
+
+// float reads the floating point setting named by key into dst.
+func (s *configSource) float(key string, dst *float64) {
+	s.parse(key, func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		*dst = f
+		return nil
+	})
+}
+
+// bool reads the boolean setting named by key into dst, and reports whether it did.
+func (s *configSource) bool(key string, dst *bool) bool {
+	return s.parse(key, func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		*dst = b
+		return nil
+	})
+}
+
+// duration reads the setting named by key, written in the format accepted by time.ParseDuration, into dst.
+func (s *configSource) duration(key string, dst *time.Duration) {
+	s.parse(key, func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", v)
+		}
+		*dst = d
+		return nil
+	})
+}
+
+// milliseconds reads the setting named by key, an integer number of milliseconds, into dst.
+func (s *configSource) milliseconds(key string, dst *time.Duration) {
+	n := int(*dst / time.Millisecond)
+	s.int(key, &n)
+	*dst = time.Duration(n) * time.Millisecond
+}
+
+// days reads the setting named by key, an integer number of days, into dst.
+func (s *configSource) days(key string, dst *time.Duration) {
+	n := int(*dst / (24 * time.Hour))
+	s.int(key, &n)
+	*dst = time.Duration(n) * 24 * time.Hour
+}
+
+// list reads the comma separated setting named by key into dst, as described by splitList.
+func (s *configSource) list(key string, dst *[]string) {
+	s.parse(key, func(v string) error {
+		*dst = splitList(v)
+		return nil
+	})
+}
+
+// splitList returns the comma separated values of v, with surrounding whitespace trimmed.
+func splitList(v string) []string {
+	values := strings.Split(v, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile reads configuration settings from the YAML file at path, or the TOML file if its
+// name ends in ".toml", so that a deployment does not have to manage dozens of environment variables.
+// The file maps the names of the environment variables read by Config.set to their values:
+//
+//	MAX_CONCURRENT: 128
+//	CORS_ALLOWED_ORIGINS: [https://example.com, https://example.org]
+//	FEATURE_SEARCH: false
+//
+// Names are case-insensitive, and lists are joined with commas. It returns the settings in the
+// format of their environment variables, keyed by the variables' names; readConfig applies them
+// before the environment, which thus takes precedence over the file.
+func loadConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CONFIG_FILE: %w", err)
+	}
+
+	var settings map[string]any
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &settings)
+	} else {
+		err = yaml.Unmarshal(data, &settings)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing CONFIG_FILE: %w", err)
+	}
+
+	values := make(map[string]string, len(settings))
+	for name, setting := range settings {
+		value, err := configFileValue(setting)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s in CONFIG_FILE: %w", name, err)
+		}
+		values[strings.ToUpper(name)] = value
+	}
+	return values, nil
+}
+
+// configFileValue returns a setting of the configuration file in the format of its environment variable.
+func configFileValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			s, err := configFileValue(item)
+			if err != nil {
+				return "", err
+			}
+			values[i] = s
+		}
+		return strings.Join(values, ","), nil
+	case map[string]any:
+		return "", fmt.Errorf("must be a single value or a list")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
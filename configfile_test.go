@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// writeConfigFile writes content to a configuration file with the given name and points CONFIG_FILE at it.
+func writeConfigFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+}
+
+func TestReadConfigFromFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "max_concurrent: 128\nCORS_ALLOWED_ORIGINS: [https://example.com, https://example.org]\nshutdown_timeout: 30s\nfeature_search: false\n",
+		"config.toml": "MAX_CONCURRENT = 128\ncors_allowed_origins = ['https://example.com', 'https://example.org']\nshutdown_timeout = '30s'\nfeature_search = false\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeConfigFile(t, name, content)
+
+			cfg, err := readConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.MaxConcurrent != 128 {
+				t.Errorf("MaxConcurrent = %d, want 128", cfg.MaxConcurrent)
+			}
+			if want := []string{"https://example.com", "https://example.org"}; !slices.Equal(cfg.CORSAllowedOrigins, want) {
+				t.Errorf("CORSAllowedOrigins = %q, want %q", cfg.CORSAllowedOrigins, want)
+			}
+			if cfg.ShutdownTimeout != 30*time.Second {
+				t.Errorf("ShutdownTimeout = %s, want 30s", cfg.ShutdownTimeout)
+			}
+			if enabled, ok := cfg.Features["search"]; !ok || enabled {
+				t.Errorf("search feature = %t, %t, want disabled", enabled, ok)
+			}
+			if cfg.CORSMaxAge != 600 {
+				t.Errorf("CORSMaxAge = %d, want the default of 600", cfg.CORSMaxAge)
+			}
+			if _, set := os.LookupEnv("MAX_CONCURRENT"); set {
+				t.Error("reading the configuration file changed the environment")
+			}
+		})
+	}
+}
+
+func TestEnvironmentOverridesConfigFile(t *testing.T) {
+	writeConfigFile(t, "config.yaml", "max_concurrent: 128\nunique_isbn: true\nfeature_search: false\n")
+	t.Setenv("MAX_CONCURRENT", "64")
+	t.Setenv("FEATURE_SEARCH", "true")
+
+	cfg, err := readConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxConcurrent != 64 {
+		t.Errorf("MaxConcurrent = %d, want the environment's 64", cfg.MaxConcurrent)
+	}
+	if !cfg.UniqueISBN {
+		t.Error("UniqueISBN = false, want the file's true")
+	}
+	if !cfg.Features["search"] {
+		t.Error("search feature disabled, want the environment to enable it")
+	}
+}
+
+func TestReadConfigRejectsInvalidFileValues(t *testing.T) {
+	for name, content := range map[string]string{
+		"not an integer": "max_concurrent: lots\n",
+		"out of range":   "max_concurrent: -1\n",
+		"nested":         "max_concurrent:\n  value: 1\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			writeConfigFile(t, "config.yaml", content)
+			if _, err := readConfig(); err == nil {
+				t.Error("readConfig succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestTracingExportsToEndpointFromConfigFile(t *testing.T) {
+	paths := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case paths <- r.URL.Path:
+		default:
+		}
+	}))
+	defer collector.Close()
+
+	writeConfigFile(t, "config.yaml", "OTEL_EXPORTER_OTLP_ENDPOINT: "+collector.URL+"/otlp\n")
+	if err := loadConfig(); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	shutdown, err := setupTracing(context.Background())
+	if err != nil {
+		t.Fatalf("setupTracing: %v", err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "test")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("flushing spans: %v", err)
+	}
+
+	select {
+	case path := <-paths:
+		if path != "/otlp/v1/traces" {
+			t.Errorf("spans posted to %s, want /otlp/v1/traces", path)
+		}
+	default:
+		t.Fatal("no spans were exported to the endpoint of the config file")
+	}
+}
+
+func TestReadConfigRejectsInvalidOTLPEndpoint(t *testing.T) {
+	writeConfigFile(t, "config.yaml", "OTEL_EXPORTER_OTLP_ENDPOINT: collector:4318\n")
+	if err := loadConfig(); err == nil {
+		t.Error("loadConfig accepted an OTLP endpoint without a scheme")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTrustedProxies parses a list of IP addresses and CIDR prefixes, e.g. "10.0.0.1,192.168.0.0/16".
+func parseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' is not an IP address or CIDR prefix", v)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not an IP address or CIDR prefix", v)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// isTrustedProxy reports whether ip is covered by trustedProxies.
+func isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitConnsPerIP returns a middleware that handles at most maxConnPerIP requests at a time from a
+// single peer IP address, so that one client cannot exhaust the server's connections. Requests
+// over the limit are rejected at once with status code 429 (Too Many Requests) and a Retry-After
+// header. Trusted proxies, which carry the requests of many clients, are not limited.
+// A limit of 0 disables the middleware.
+func limitConnsPerIP() gin.HandlerFunc {
+	if maxConnPerIP == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var mu sync.Mutex
+	open := map[string]int{}
+
+	return func(c *gin.Context) {
+		ip := c.RemoteIP()
+		if isTrustedProxy(ip) {
+			c.Next()
+			return
+		}
+
+		mu.Lock()
+		if open[ip] >= maxConnPerIP {
+			mu.Unlock()
+			setRetryAfter(c, time.Second)
+			respondError(c, http.StatusTooManyRequests, "too many concurrent requests from this address, at most "+strconv.Itoa(maxConnPerIP)+" are allowed")
+			c.Abort()
+			return
+		}
+		open[ip]++
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			if open[ip]--; open[ip] == 0 {
+				delete(open, ip)
+			}
+			mu.Unlock()
+		}()
+
+		c.Next()
+	}
+}
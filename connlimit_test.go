@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveFrom sends a GET request for path to router from the peer address remoteAddr.
+func serveFrom(router http.Handler, remoteAddr, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimitConnsPerIP(t *testing.T) {
+	for _, trusted := range []bool{false, true} {
+		name := "untrusted"
+		if trusted {
+			name = "trusted proxy"
+			t.Setenv("TRUSTED_PROXIES", "192.0.2.1")
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("MAX_CONN_PER_IP", "1")
+			router := newTestRouter(t)
+			entered, release := make(chan struct{}), make(chan struct{})
+			router.GET("/block", func(c *gin.Context) {
+				close(entered)
+				<-release
+				c.Status(http.StatusNoContent)
+			})
+
+			done := make(chan *httptest.ResponseRecorder)
+			go func() { done <- serveFrom(router, "192.0.2.1:1000", "/block") }()
+			<-entered
+
+			want := http.StatusTooManyRequests
+			if trusted {
+				want = http.StatusOK
+			}
+			expectStatus(t, serveFrom(router, "192.0.2.1:1001", "/books/1"), want)
+			expectStatus(t, serveFrom(router, "198.51.100.7:1000", "/books/1"), http.StatusOK)
+
+			close(release)
+			expectStatus(t, <-done, http.StatusNoContent)
+			expectStatus(t, serveFrom(router, "192.0.2.1:1002", "/books/1"), http.StatusOK)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// bookCopy is a single physical copy of a book, identified by its barcode.
+type bookCopy struct {
+	Barcode   string `json:"barcode" binding:"required"`
+	Available bool   `json:"available"`
+}
+
+// UnmarshalJSON decodes a copy, treating a copy without an "available" field as available,
+// so that new copies can be listed by barcode alone.
+func (bc *bookCopy) UnmarshalJSON(data []byte) error {
+	type plain bookCopy
+	p := plain{Available: true}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*bc = bookCopy(p)
+	return nil
+}
+
+// tracksCopies reports whether the book tracks individual copies rather than just a quantity.
+// The quantity of such a book is derived from its copies: it is the number of available copies.
+func (b *book) tracksCopies() bool {
+	return len(b.Copies) > 0
+}
+
+// availableCopies returns the number of copies of the book that are not checked out.
+func (b *book) availableCopies() quantity {
+	var n quantity
+	for _, bc := range b.Copies {
+		if bc.Available {
+			n++
+		}
+	}
+	return n
+}
+
+// validateCopies returns an error if two copies of the book share a barcode.
+func (b *book) validateCopies() error {
+	seen := make(map[string]bool, len(b.Copies))
+	for _, bc := range b.Copies {
+		if seen[bc.Barcode] {
+			return fmt.Errorf("duplicate barcode '%s'", bc.Barcode)
+		}
+		seen[bc.Barcode] = true
+	}
+	return nil
+}
+
+// findCopy returns a pointer to the copy of the book with the given barcode, or nil if there is none.
+func (b *book) findCopy(barcode string) *bookCopy {
+	for i := range b.Copies {
+		if b.Copies[i].Barcode == barcode {
+			return &b.Copies[i]
+		}
+	}
+	return nil
+}
+
+// cloneBooks returns a copy of books that shares no copies or tags with the original, so that it
+// can be read after storeMu is released.
+func cloneBooks(books []book) []book {
+	clone := append([]book{}, books...)
+	for i := range clone {
+		clone[i].Copies = slices.Clone(clone[i].Copies)
+		clone[i].Tags = slices.Clone(clone[i].Tags)
+	}
+	return clone
+}
+
+// errDerivedQuantity is returned when a client tries to set the quantity of a book that tracks copies.
+var errDerivedQuantity = errors.New("the quantity of a book with copies is the number of its available copies and cannot be set")
+
+// errCopyNotFound is returned by takeCopy if the book has no copy with the requested barcode.
+var errCopyNotFound = errors.New("copy not found")
+
+// takeCopy takes one copy of the book off the shelf and decrements its quantity.
+// For a book that tracks copies, the copy with the given barcode is marked unavailable, or the
+// first available copy if barcode is empty; the barcode of the copy taken is returned.
+// For any other book, barcode must be empty. The caller must have checked that the book is available.
+// Callers must hold storeMu.
+func (b *book) takeCopy(barcode string) (string, error) {
+	if !b.tracksCopies() {
+		if barcode != "" {
+			return "", errors.New("book does not track individual copies")
+		}
+		b.Quantity -= 1
+		return "", nil
+	}
+
+	var bc *bookCopy
+	if barcode == "" {
+		for i := range b.Copies {
+			if b.Copies[i].Available {
+				bc = &b.Copies[i]
+				break
+			}
+		}
+	} else if bc = b.findCopy(barcode); bc == nil {
+		return "", errCopyNotFound
+	}
+
+	if bc == nil {
+		return "", errors.New("no copy is available at the moment")
+	}
+	if !bc.Available {
+		return "", errors.New("copy '" + bc.Barcode + "' is not available at the moment")
+	}
+
+	bc.Available = false
+	b.Quantity = b.availableCopies()
+	return bc.Barcode, nil
+}
+
+// returnCopy puts the copy with the given barcode back on the shelf and increments the book's quantity.
+// For a book that does not track copies, or a barcode the book does not know, only the quantity is incremented.
+// Callers must hold storeMu.
+func (b *book) returnCopy(barcode string) {
+	bc := b.findCopy(barcode)
+	if bc == nil {
+		b.Quantity += 1
+		return
+	}
+
+	bc.Available = true
+	b.Quantity = b.availableCopies()
+}
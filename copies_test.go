@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestCheckoutSpecificCopy(t *testing.T) {
+	router := newTestRouter(t)
+	w := serve(router, http.MethodPost, "/books", `{"id":"5","title":"Atlas","copies":[{"barcode":"A-1"},{"barcode":"A-2"},{"barcode":"A-3","available":false}]}`)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.Quantity != 2 {
+		t.Fatalf("quantity = %d, want the 2 available copies", b.Quantity)
+	}
+
+	w = serve(router, http.MethodPatch, "/checkout?id=5&user=ann&barcode=A-2", "")
+	expectStatus(t, w, http.StatusOK)
+	b := decode[checkoutResponse](t, w).Data
+	if b.Quantity != 1 || b.findCopy("A-2").Available || !b.findCopy("A-1").Available {
+		t.Errorf("book after checking out A-2 = %+v, want only A-2 taken", b)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&barcode=A-2", ""), http.StatusUnprocessableEntity)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=5&barcode=A-9", ""), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1&barcode=A-1", ""), http.StatusUnprocessableEntity)
+
+	w = serve(router, http.MethodPatch, "/return?id=5&user=ann", "")
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.Quantity != 2 || !b.findCopy("A-2").Available {
+		t.Errorf("book after returning A-2 = %+v, want A-2 back on the shelf", b)
+	}
+}
+
+func TestCreateBookRejectsDuplicateBarcodes(t *testing.T) {
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Atlas","copies":[{"barcode":"A-1"},{"barcode":"A-1"}]}`), http.StatusBadRequest)
+}
+
+// TestListingsDoNotShareCopies checks, when run with -race, that listings marshal copies of the
+// books' copies rather than the store's, which checkouts and returns change in place.
+func TestListingsDoNotShareCopies(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Atlas","author":"Mr. Golang","copies":[{"barcode":"A-1"}]}`), http.StatusCreated)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			serve(router, http.MethodPatch, "/checkout?id=5&user=ann", "")
+			serve(router, http.MethodPatch, "/return?id=5&user=ann", "")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 25; i++ {
+			for _, path := range []string{"/books", "/books/by-author", "/books/export.ndjson", "/books/recent", "/books/out-of-stock"} {
+				expectStatus(t, serve(router, http.MethodGet, path, ""), http.StatusOK)
+			}
+		}
+	}()
+	wg.Wait()
+}
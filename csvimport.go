@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvImportColumns are the columns of a CSV import, which its header row must name.
+var csvImportColumns = []string{"id", "title", "author", "quantity"}
+
+// csvRowError is the reason a single row of a CSV import failed.
+// Row is the line number of the row in the file, where the header row is line 1.
+type csvRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// csvImportSummary is the response of importBooksCSV.
+type csvImportSummary struct {
+	Inserted int           `json:"inserted"`
+	Updated  int           `json:"updated"`
+	Failed   int           `json:"failed"`
+	Errors   []csvRowError `json:"errors"`
+}
+
+// importBooksCSV upserts books from a CSV file uploaded as the 'file' field of a multipart form.
+// The first row must be a header naming the columns id, title, author, and quantity, in any order;
+// a leading byte order mark is ignored, and fields may be quoted.
+// A row whose ID is not in use creates a book, with an empty author or quantity replaced by the
+// configured default; any other row replaces the title, author, and quantity of the book with its ID,
+// keeping the existing author or quantity when that field is empty.
+// Every row needs an ID, and rows repeating the ID of an earlier row fail.
+// Unlike importBooks, rows are imported independently: a row that fails is reported with its line
+// number and the reason, and the other rows are still imported.
+// If the upload cannot be read to its end, the rows before the failure are kept and the failure
+// is reported like a failed row.
+// It returns the number of inserted, updated, and failed rows with status code 200 (OK), or a 400
+// status code if the upload or its header row is missing or malformed.
+func importBooksCSV(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "missing CSV upload in form field 'file'")
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "missing CSV header row")
+		return
+	}
+	columns, err := csvColumns(header)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	summary := lib.importCSVRows(r, header, columns, serverClock.Now())
+	if summary.Inserted+summary.Updated > 0 {
+		markStoreChanged()
+	}
+
+	respondJSON(c, http.StatusOK, summary)
+}
+
+// csvColumns returns the index of each of csvImportColumns in the header row of a CSV import,
+// or an error if a column is missing or named twice.
+func csvColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("duplicate CSV column '%s'", name)
+		}
+		columns[name] = i
+	}
+
+	for _, name := range csvImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column '%s'", name)
+		}
+	}
+	return columns, nil
+}
+
+// importCSVRows upserts the book described by every row r reads after the header row, and
+// summarizes the outcome. If r fails to read a row other than because it is malformed, the error
+// is reported for the line after the last row read and the rest of the file is skipped.
+// Callers must hold storeMu.
+func (l *library) importCSVRows(r *csv.Reader, header []string, columns map[string]int, now time.Time) csvImportSummary {
+	summary := csvImportSummary{Errors: []csvRowError{}}
+	seen := map[string]bool{}
+	last, _ := r.FieldPos(0)
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var row int
+		var inserted bool
+		var perr *csv.ParseError
+		switch {
+		case errors.As(err, &perr):
+			row, err = perr.StartLine, perr.Err
+		case err != nil:
+			summary.Failed++
+			summary.Errors = append(summary.Errors, csvRowError{Row: last + 1, Error: err.Error()})
+			return summary
+		case len(record) != len(header):
+			row, _ = r.FieldPos(0)
+			err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+		default:
+			row, _ = r.FieldPos(0)
+			id := strings.TrimSpace(record[columns["id"]])
+			if id != "" && seen[id] {
+				err = fmt.Errorf("duplicate id '%s' in import", id)
+				break
+			}
+			seen[id] = true
+			inserted, err = l.upsertCSVRecord(record, columns, now)
+		}
+		last = row
+
+		switch {
+		case err != nil:
+			summary.Failed++
+			summary.Errors = append(summary.Errors, csvRowError{Row: row, Error: err.Error()})
+		case inserted:
+			summary.Inserted++
+		default:
+			summary.Updated++
+		}
+	}
+	return summary
+}
+
+// upsertCSVRecord creates or updates the book described by a single CSV record, and reports
+// whether it was created. Callers must hold storeMu.
+func (l *library) upsertCSVRecord(record []string, columns map[string]int, now time.Time) (bool, error) {
+	field := func(name string) string {
+		return strings.TrimSpace(record[columns[name]])
+	}
+
+	id := field("id")
+	if id == "" {
+		return false, errors.New("missing id")
+	}
+
+	var req createBookRequest
+	req.ID, req.Title = id, field("title")
+	if author := field("author"); author != "" {
+		req.Author = &author
+	}
+	if raw := field("quantity"); raw != "" {
+		var q quantity
+		if err := q.UnmarshalJSON([]byte(strconv.Quote(raw))); err != nil {
+			return false, err
+		}
+		if q < 0 {
+			return false, fmt.Errorf("invalid quantity %d: must not be negative", q)
+		}
+		req.Quantity = &q
+	}
+
+	existing, err := l.getBookById(id)
+	if err == nil {
+		if req.Author == nil {
+			req.Author = &existing.Author
+		}
+		if req.Quantity == nil {
+			req.Quantity = &existing.Quantity
+		}
+	}
+
+	b, err := req.newBook(now)
+	if err != nil {
+		return false, err
+	}
+	if err := maxQuantityError(b.Quantity); err != nil {
+		return false, err
+	}
+
+	if err := l.titleAuthorConflict(b.Title, b.Author, id); err != nil {
+		return false, err
+	}
+
+	if existing == nil {
+		l.books = append(l.books, b)
+		return true, nil
+	}
+
+	if existing.tracksCopies() && b.Quantity != existing.Quantity {
+		return false, errDerivedQuantity
+	}
+	updated := *existing
+	updated.Title, updated.Author, updated.Quantity = b.Title, b.Author, b.Quantity
+	if err := updated.validateReserved(); err != nil {
+		return false, err
+	}
+
+	existing.Title, existing.Author, existing.Quantity = b.Title, b.Author, b.Quantity
+	existing.touch(now)
+	return false, nil
+}
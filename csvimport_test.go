@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// serveCSV uploads content as the CSV file of a POST /books/import to router.
+func serveCSV(t *testing.T, router http.Handler, content string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "books.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/books/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportBooksCSVRejectsRepeatedIDs(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serveCSV(t, router, "id,title,author,quantity\n5,First,A,1\n,No ID,A,1\n5,Second,A,2\n")
+	expectStatus(t, w, http.StatusOK)
+	summary := decode[csvImportSummary](t, w)
+	if summary.Inserted != 1 || summary.Failed != 2 {
+		t.Fatalf("summary = %+v, want 1 inserted and 2 failed", summary)
+	}
+	if summary.Errors[0].Row != 3 || summary.Errors[1].Row != 4 {
+		t.Errorf("errors = %+v, want rows 3 and 4", summary.Errors)
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); b.Title != "First" {
+		t.Errorf("title = %q, want the first row's", b.Title)
+	}
+}
+
+func TestImportBooksCSVUpdateKeepsEmptyFields(t *testing.T) {
+	t.Setenv("DEFAULT_AUTHOR", "Anonymous")
+	router := newTestRouter(t)
+
+	w := serveCSV(t, router, "id,title,author,quantity\n2,Goroutines 2nd ed.,,\n5,New,,\n")
+	expectStatus(t, w, http.StatusOK)
+	if summary := decode[csvImportSummary](t, w); summary.Inserted != 1 || summary.Updated != 1 {
+		t.Fatalf("summary = %+v, want 1 inserted and 1 updated", summary)
+	}
+
+	updated := decode[book](t, serve(router, http.MethodGet, "/books/2", ""))
+	if updated.Title != "Goroutines 2nd ed." || updated.Author != "Mr. Goroutine" || updated.Quantity != 20 {
+		t.Errorf("updated book = %+v, want the new title with the existing author and quantity", updated)
+	}
+	if created := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); created.Author != "Anonymous" {
+		t.Errorf("created author = %q, want the default", created.Author)
+	}
+}
+
+func TestImportCSVRowsStopsAtReadError(t *testing.T) {
+	input := io.MultiReader(strings.NewReader("id,title,author,quantity\n5,First,A,1\n"), iotest.ErrReader(errors.New("connection reset")))
+	r := csv.NewReader(input)
+	header, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns, err := csvColumns(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan csvImportSummary)
+	go func() {
+		done <- newLibrary(nil).importCSVRows(r, header, columns, serverClock.Now())
+	}()
+	select {
+	case summary := <-done:
+		if summary.Inserted != 1 || summary.Failed != 1 {
+			t.Fatalf("summary = %+v, want 1 inserted and 1 failed", summary)
+		}
+		if want := (csvRowError{Row: 3, Error: "connection reset"}); summary.Errors[0] != want {
+			t.Errorf("error = %+v, want %+v", summary.Errors[0], want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("import did not stop at the read error")
+	}
+}
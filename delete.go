@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkDeleteRequest is the JSON payload of deleteBooksByIDs.
+type bulkDeleteRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,dive,required"`
+}
+
+// bulkDeleteResponse is the response of a successful deleteBooksByIDs.
+type bulkDeleteResponse struct {
+	Message    string   `json:"message"`
+	Deleted    []string `json:"deleted"`
+	NotFound   []string `json:"not_found"`
+	CheckedOut []string `json:"checked_out"`
+}
+
+// deleteBooks handles DELETE /books: a request with a body deletes the books listed in it as
+// described by deleteBooksByIDs, any other request deletes by author as described by deleteBooksByAuthor.
+func deleteBooks(c *gin.Context) {
+	if c.Request.ContentLength != 0 {
+		deleteBooksByIDs(c)
+		return
+	}
+	deleteBooksByAuthor(c)
+}
+
+// deleteBooksByIDs deletes the books with the given IDs.
+// It expects a JSON payload in the request body with the following format:
+//
+//	{
+//	  "ids": ["string"]
+//	}
+//
+// Books with outstanding checkouts are never deleted, so that no checkout is left referring to a
+// book that no longer exists; they have to be returned first.
+// By default the delete is atomic: if any ID does not exist, nothing is deleted and a 404 status
+// code is sent with the result of each ID, and if any book is checked out, a 409 status code.
+// With the query parameter 'partial=true', the books that can be deleted are deleted and the deleted,
+// unknown, and checked out IDs are returned with status code 207 (Multi-Status).
+// On success it returns the deleted IDs with status code 200 (OK). An empty list is rejected with 400.
+func deleteBooksByIDs(c *gin.Context) {
+	var req bulkDeleteRequest
+
+	partial, err := strconv.ParseBool(c.DefaultQuery("partial", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("partial", "a boolean").Error())
+		return
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	resp := bulkDeleteResponse{Message: "success", Deleted: []string{}, NotFound: []string{}, CheckedOut: []string{}}
+	results := make([]batchItemResult, 0, len(req.IDs))
+	remove := map[string]bool{}
+
+	for _, id := range req.IDs {
+		if remove[id] || slices.Contains(resp.NotFound, id) || slices.Contains(resp.CheckedOut, id) {
+			continue
+		}
+		if _, err := lib.getBookById(id); err != nil {
+			resp.NotFound = append(resp.NotFound, id)
+			results = append(results, batchItemResult{ID: id, Error: "book not found"})
+			continue
+		}
+		if lib.isCheckedOut(id) {
+			resp.CheckedOut = append(resp.CheckedOut, id)
+			results = append(results, batchItemResult{ID: id, Error: "book has outstanding checkouts"})
+			continue
+		}
+		remove[id] = true
+		resp.Deleted = append(resp.Deleted, id)
+		results = append(results, batchItemResult{ID: id, OK: true})
+	}
+
+	if len(resp.NotFound) > 0 && !partial {
+		respondErrorDetails(c, http.StatusNotFound, "no books were deleted", errorDetails{Results: results})
+		return
+	}
+	if len(resp.CheckedOut) > 0 && !partial {
+		respondErrorDetails(c, http.StatusConflict, "no books were deleted, some are checked out", errorDetails{Results: results})
+		return
+	}
+
+	lib.books = slices.DeleteFunc(lib.books, func(b book) bool { return remove[b.ID] })
+	if len(remove) > 0 {
+		markStoreChanged()
+	}
+
+	if partial {
+		if len(resp.NotFound) > 0 || len(resp.CheckedOut) > 0 {
+			resp.Message = "some books were not deleted"
+		}
+		respondJSON(c, http.StatusMultiStatus, resp)
+		return
+	}
+
+	respondJSON(c, http.StatusOK, resp)
+}
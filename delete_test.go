@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestDeleteBooksByIDs(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+
+	t.Run("atomic", func(t *testing.T) {
+		router := newTestRouter(t)
+
+		w := serve(router, http.MethodDelete, "/books", `{"ids":["1","42"]}`, "X-API-Key", "secret")
+		expectStatus(t, w, http.StatusNotFound)
+		results := decode[errorResponse](t, w).Results
+		if len(results) != 2 || !results[0].OK || results[1].OK {
+			t.Errorf("results = %+v, want 1 ok and 42 not found", results)
+		}
+		expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusOK)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		router := newTestRouter(t)
+
+		w := serve(router, http.MethodDelete, "/books?partial=true", `{"ids":["1","42"]}`, "X-API-Key", "secret")
+		expectStatus(t, w, http.StatusMultiStatus)
+		resp := decode[bulkDeleteResponse](t, w)
+		if !slices.Equal(resp.Deleted, []string{"1"}) || !slices.Equal(resp.NotFound, []string{"42"}) {
+			t.Errorf("response = %+v, want 1 deleted and 42 not found", resp)
+		}
+		expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusNotFound)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		router := newTestRouter(t)
+		expectStatus(t, serve(router, http.MethodDelete, "/books", `{"ids":[]}`, "X-API-Key", "secret"), http.StatusBadRequest)
+	})
+}
+
+func TestDeleteBooksByIDsKeepsCheckedOutBooks(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+
+	expectStatus(t, serve(router, http.MethodDelete, "/books", `{"ids":["1","2"]}`, "X-API-Key", "secret"), http.StatusConflict)
+	expectStatus(t, serve(router, http.MethodGet, "/books/2", ""), http.StatusOK)
+
+	w := serve(router, http.MethodDelete, "/books?partial=true", `{"ids":["1","2"]}`, "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusMultiStatus)
+	if resp := decode[bulkDeleteResponse](t, w); !slices.Equal(resp.Deleted, []string{"2"}) || !slices.Equal(resp.CheckedOut, []string{"1"}) {
+		t.Errorf("response = %+v, want 2 deleted and 1 checked out", resp)
+	}
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	if v := libraries[defaultTenant].integrityViolations(); len(v) > 0 {
+		t.Errorf("integrity violations after delete: %v", v)
+	}
+}
+
+func TestDeleteBooksByAuthor(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"More pointers","author":"Mr. Golang"}`), http.StatusCreated)
+
+	expectStatus(t, serve(router, http.MethodDelete, "/books?confirm=true", "", "X-API-Key", "secret"), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodDelete, "/books?author=Mr.+Golang", "", "X-API-Key", "secret"), http.StatusBadRequest)
+
+	w := serve(router, http.MethodDelete, "/books?author=Mr.+Golang&confirm=true", "", "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusOK)
+	if deleted := decode[deleteResponse](t, w).Deleted; deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+
+	var ids []string
+	for _, b := range decode[[]book](t, serve(router, http.MethodGet, "/books", "")) {
+		ids = append(ids, b.ID)
+	}
+	if !slices.Equal(ids, []string{"2", "3", "4"}) {
+		t.Errorf("remaining books = %v, want 2, 3, 4", ids)
+	}
+}
+
+func TestDeleteBooksByAuthorKeepsCheckedOutBooks(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+
+	expectStatus(t, serve(router, http.MethodDelete, "/books?author=Mr.+Golang&confirm=true", "", "X-API-Key", "secret"), http.StatusConflict)
+	expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusOK)
+
+	w := serve(router, http.MethodGet, "/books/stats", "")
+	if stats := decode[bookStats](t, w); stats.Books != 4 || stats.CheckedOut != 1 {
+		t.Errorf("stats = %+v, want 4 books and 1 checked out", stats)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error formats selectable with the ERROR_FORMAT environment variable.
+const (
+	// errorFormatSimple renders errors as {"message": "..."}.
+	errorFormatSimple = "simple"
+	// errorFormatProblem renders errors as RFC 7807 problem details.
+	errorFormatProblem = "problem"
+)
+
+// problemContentType is the media type of RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// errorDetails holds the optional members some error responses carry in addition to their message.
+// Unset members are left out of the response.
+type errorDetails struct {
+	// Suggestions lists books the client might have meant, when a lookup with 'suggest=true' fails.
+	// It is a pointer so that an empty list of suggestions is still reported.
+	Suggestions *[]bookSuggestion `json:"suggestions,omitempty"`
+	// Results reports the outcome of each item of a failed batch operation.
+	Results []batchItemResult `json:"results,omitempty"`
+	// UpdatedAt is the last modification time of a book that failed a precondition.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// errorResponse is the body of an error response in the simple format.
+type errorResponse struct {
+	Message string `json:"message"`
+	errorDetails
+}
+
+// problemResponse is the body of an error response in the problem format, an RFC 7807
+// problem details object with the request ID and error details as extension members.
+type problemResponse struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance"`
+	RequestID string `json:"request_id"`
+	errorDetails
+}
+
+// respondError sends an error response with the given status code and message,
+// in the configured error format.
+func respondError(c *gin.Context, status int, message string) {
+	respondErrorDetails(c, status, message, errorDetails{})
+}
+
+// respondErrorDetails is like respondError, but adds the given details to the error body.
+//
+// In the simple format the body is the message together with the details:
+//
+//	{"message": "book not found", ...details}
+//
+// In the problem format the body is an RFC 7807 problem details object served as
+// application/problem+json, with the details added as extension members:
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Not Found",
+//	  "status": 404,
+//	  "detail": "book not found",
+//	  "instance": "/books/42",
+//	  "request_id": "...",
+//	  ...details
+//	}
+func respondErrorDetails(c *gin.Context, status int, message string, details errorDetails) {
+	if errorFormat != errorFormatProblem {
+		respondJSON(c, status, errorResponse{Message: message, errorDetails: details})
+		return
+	}
+
+	data, err := marshalJSON(problemResponse{
+		Type:         "about:blank",
+		Title:        http.StatusText(status),
+		Status:       status,
+		Detail:       message,
+		Instance:     c.Request.URL.Path,
+		RequestID:    c.GetString(requestIDKey),
+		errorDetails: details,
+	}, prettyPrint(c))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(status, withCharset(problemContentType), data)
+}
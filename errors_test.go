@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorFormats(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/books/42", "")
+	expectStatus(t, w, http.StatusNotFound)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want JSON", ct)
+	}
+	if got := decode[errorResponse](t, w).Message; got == "" {
+		t.Error("simple error has no message")
+	}
+
+	t.Setenv("ERROR_FORMAT", "problem")
+	router = newTestRouter(t)
+
+	w = serve(router, http.MethodGet, "/books/42", "", "X-Request-ID", "req-1")
+	expectStatus(t, w, http.StatusNotFound)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	problem := decode[problemResponse](t, w)
+	want := problemResponse{
+		Type:      "about:blank",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    problem.Detail,
+		Instance:  "/books/42",
+		RequestID: "req-1",
+	}
+	if problem.Detail == "" || !reflect.DeepEqual(problem, want) {
+		t.Errorf("problem = %+v, want %+v with a detail", problem, want)
+	}
+}
+
+func TestStatusCodesSeparateSyntaxFromBusinessRules(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"quantity":0}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Atlas","copies":[{"barcode":"A-1"}]}`), http.StatusCreated)
+
+	for _, tc := range []struct {
+		name         string
+		method, path string
+		body         string
+		status       int
+	}{
+		{"malformed JSON", http.MethodPost, "/books", `{"id":`, http.StatusBadRequest},
+		{"invalid field", http.MethodPost, "/books", `{"id":"6","title":"Negative","quantity":-1}`, http.StatusBadRequest},
+		{"missing query parameter", http.MethodPatch, "/checkout", "", http.StatusBadRequest},
+		{"unknown book", http.MethodPatch, "/checkout?id=42", "", http.StatusNotFound},
+		{"checkout out of stock", http.MethodPatch, "/checkout?id=1", "", http.StatusUnprocessableEntity},
+		{"return not checked out", http.MethodPatch, "/return?id=2", "", http.StatusUnprocessableEntity},
+		{"batch return not checked out", http.MethodPost, "/return/batch", `{"user":"ann","ids":["2","3"]}`, http.StatusUnprocessableEntity},
+		{"quantity of a book with copies", http.MethodPatch, "/books/5", `{"quantity":3}`, http.StatusUnprocessableEntity},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expectStatus(t, serve(router, tc.method, tc.path, tc.body), tc.status)
+		})
+	}
+}
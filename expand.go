@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// knownExpansions lists the values accepted by the 'expand' query parameter.
+var knownExpansions = []string{"checkouts"}
+
+// expandCheckouts reports whether the request asks for the checkouts of books to be embedded in the
+// response with 'expand=checkouts'. The 'expand' query parameter is a comma separated list of
+// knownExpansions; an error is returned for any other value.
+func expandCheckouts(c *gin.Context) (bool, error) {
+	v, ok := c.GetQuery("expand")
+	if !ok {
+		return false, nil
+	}
+
+	checkouts := false
+	for _, name := range strings.Split(v, ",") {
+		switch strings.TrimSpace(name) {
+		case "checkouts":
+			checkouts = true
+		default:
+			return false, fmt.Errorf("unknown expansion '%s', expected one of: %s", name, strings.Join(knownExpansions, ", "))
+		}
+	}
+	return checkouts, nil
+}
+
+// expandedBook is a book together with its outstanding checkouts, as returned with 'expand=checkouts'.
+type expandedBook struct {
+	book
+	Checkouts []checkout
+}
+
+// MarshalJSON encodes the book with its checkouts added. Without it, the book's MarshalJSON would
+// be promoted and the checkouts left out.
+func (e expandedBook) MarshalJSON() ([]byte, error) {
+	type plain book
+	b := plain(e.book)
+	b.AvailableForCheckout = e.availableForCheckout()
+	return json.Marshal(struct {
+		plain
+		Checkouts []checkout `json:"checkouts"`
+	}{b, e.Checkouts})
+}
+
+// withCheckouts returns the given books together with their outstanding checkouts in the library, oldest first.
+// Callers must hold storeMu.
+func (l *library) withCheckouts(books []book) []expandedBook {
+	byBook := make(map[string][]checkout, len(books))
+	for _, co := range l.checkouts {
+		byBook[co.BookID] = append(byBook[co.BookID], co)
+	}
+
+	expanded := make([]expandedBook, 0, len(books))
+	for _, b := range books {
+		checkouts := byBook[b.ID]
+		if checkouts == nil {
+			checkouts = []checkout{}
+		}
+		expanded = append(expanded, expandedBook{book: b, Checkouts: checkouts})
+	}
+	return expanded
+}
+
+// checkoutsOf returns the outstanding checkouts of the book with the given ID in the library, oldest first.
+// Callers must hold storeMu.
+func (l *library) checkoutsOf(bookID string) []checkout {
+	checkouts := []checkout{}
+	for _, co := range l.checkouts {
+		if co.BookID == bookID {
+			checkouts = append(checkouts, co)
+		}
+	}
+	return checkouts
+}
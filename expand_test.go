@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExpandCheckouts(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+
+	type withCheckouts struct {
+		ID        string
+		Checkouts *[]checkout
+	}
+	for _, path := range []string{"/books/2", "/books"} {
+		w := serve(router, http.MethodGet, path, "")
+		expectStatus(t, w, http.StatusOK)
+		if strings.Contains(w.Body.String(), `"checkouts"`) {
+			t.Errorf("GET %s has checkouts without expand=checkouts: %s", path, w.Body.String())
+		}
+	}
+
+	w := serve(router, http.MethodGet, "/books?expand=checkouts", "")
+	expectStatus(t, w, http.StatusOK)
+	for _, b := range decode[[]withCheckouts](t, w) {
+		want := 0
+		if b.ID == "2" {
+			want = 1
+		}
+		if b.Checkouts == nil || len(*b.Checkouts) != want {
+			t.Errorf("book %s has checkouts %v, want %d", b.ID, b.Checkouts, want)
+		}
+	}
+
+	w = serve(router, http.MethodGet, "/books/2?expand=checkouts", "")
+	expectStatus(t, w, http.StatusOK)
+	var b struct {
+		book
+		Checkouts []checkout `json:"checkouts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &b); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Checkouts) != 1 || b.Checkouts[0].User != "ann" || b.Checkouts[0].DueAt.IsZero() || b.Title != "Goroutines" {
+		t.Errorf("expanded book = %+v, want Goroutines with ann's checkout and its due date", b)
+	}
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/2?expand=reviews", ""), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodGet, "/books?expand=reviews", ""), http.StatusBadRequest)
+}
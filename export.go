@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportChunkSize is the number of books exportNDJSON copies out of the store and writes
+// between flushes.
+const exportChunkSize = 100
+
+// exportNDJSON streams the tenant's books as newline-delimited JSON, one book per line.
+// Books are copied out of the store in chunks of exportChunkSize and the response is flushed
+// after each chunk, so memory use stays flat regardless of the size of the catalog and the
+// store is never locked while writing to a slow client. Books created or deleted while the
+// export is running may therefore be missed or, at chunk boundaries, repeated. The export stops
+// between chunks once the client has disconnected.
+//
+// The response carries a weak ETag identifying the version of the export, and a request whose
+// If-None-Match header matches it receives an empty 304 (Not Modified). Requests with a Range
+// header, such as resumed downloads, are served as described by serveExportRange instead.
+// The response may be cached as described by setExportCaching.
+func exportNDJSON(c *gin.Context) {
+	lib := currentLibrary(c)
+
+	c.Header("Content-Type", withCharset("application/x-ndjson"))
+	c.Header("Accept-Ranges", "bytes")
+	setExportCaching(c)
+
+	if c.GetHeader("Range") != "" {
+		serveExportRange(c, lib)
+		return
+	}
+
+	storeMu.RLock()
+	etag := lib.exportETag()
+	storeMu.RUnlock()
+
+	c.Header("ETag", etag)
+	if etagMatches(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Status(http.StatusOK)
+
+	chunk := make([]book, 0, exportChunkSize)
+
+	for offset := 0; ; offset += exportChunkSize {
+		if err := c.Request.Context().Err(); err != nil {
+			c.Error(err)
+			return
+		}
+
+		storeMu.RLock()
+		chunk = chunk[:0]
+		if offset < len(lib.books) {
+			chunk = append(chunk, cloneBooks(lib.books[offset:min(offset+exportChunkSize, len(lib.books))])...)
+		}
+		storeMu.RUnlock()
+
+		if len(chunk) == 0 {
+			return
+		}
+
+		for i := range chunk {
+			line, err := marshalJSON(&chunk[i], false)
+			if err != nil {
+				c.Error(err)
+				return
+			}
+			c.Writer.Write(append(line, '\n'))
+		}
+		c.Writer.Flush()
+	}
+}
+
+// serveExportRange serves the byte ranges of the export requested by the Range header with
+// status code 206 (Partial Content) and a Content-Range header, so that interrupted downloads
+// can be resumed. The whole export is rendered from a single consistent copy of the books, and
+// clients resuming a download should check that its ETag matches the one of the original
+// response. An If-Range header never matches the weak ETag, so such requests get the full export.
+func serveExportRange(c *gin.Context, lib *library) {
+	storeMu.RLock()
+	books := cloneBooks(lib.books)
+	etag := lib.exportETag()
+	storeMu.RUnlock()
+
+	var buf bytes.Buffer
+	for i := range books {
+		line, err := marshalJSON(&books[i], false)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "failed to export books")
+			return
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	c.Header("ETag", etag)
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
+
+// exportETag returns a weak ETag identifying the version of the library's books. It changes
+// whenever a book is added, removed, or modified, but is cheaper to compute than a hash of the
+// export itself, which is why it is weak.
+// Callers must hold storeMu.
+func (l *library) exportETag() string {
+	h := sha256.New()
+	for _, b := range l.books {
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", b.ID, b.UpdatedAt.UnixNano(), b.Quantity)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// setExportCaching adds the headers that let clients and CDNs cache an export for exportMaxAge
+// seconds, after which they revalidate it with its ETag. Exports differ between tenants, so caches
+// must keep them apart by X-Tenant-ID header.
+func setExportCaching(c *gin.Context) {
+	if exportMaxAge > 0 {
+		c.Header("Cache-Control", "max-age="+strconv.Itoa(exportMaxAge))
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.Writer.Header().Add("Vary", "X-Tenant-ID")
+}
+
+// changesResponse is the response of exportChanges. ServerTime is the time the books were read
+// at, to be sent as the 'since' of the next request.
+type changesResponse struct {
+	ServerTime time.Time `json:"server_time"`
+	Books      []book    `json:"books"`
+}
+
+// exportChanges returns the tenant's books created or updated after the time given in the 'since'
+// query parameter, in RFC 3339 format, for incremental sync; without 'since' every book is returned.
+// The response includes the server time the books were read at, which a client polling for changes
+// sends as the 'since' of its next request. Deleted books are not reported.
+// Like exportNDJSON, the response carries a weak ETag and may be cached, and a request whose
+// If-None-Match header matches the ETag receives an empty 304 (Not Modified) response.
+// It returns a 400 status code if 'since' is not a valid timestamp.
+func exportChanges(c *gin.Context) {
+	var since time.Time
+	if s, ok := c.GetQuery("since"); ok {
+		var err error
+		if since, err = time.Parse(time.RFC3339, s); err != nil {
+			respondError(c, http.StatusBadRequest, errInvalidQuery("since", "an RFC 3339 timestamp").Error())
+			return
+		}
+	}
+
+	lib := currentLibrary(c)
+	setExportCaching(c)
+
+	storeMu.RLock()
+	etag := lib.exportETag()
+	if etagMatches(c, etag) {
+		storeMu.RUnlock()
+		c.Header("ETag", etag)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	result := changesResponse{ServerTime: serverClock.Now().UTC(), Books: []book{}}
+	for i := range lib.books {
+		if lib.books[i].UpdatedAt.After(since) {
+			result.Books = append(result.Books, cloneBooks(lib.books[i:i+1])...)
+		}
+	}
+	storeMu.RUnlock()
+
+	c.Header("ETag", etag)
+	respondJSON(c, http.StatusOK, result)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportNDJSONStreamsOneBookPerLine(t *testing.T) {
+	router := newTestRouter(t)
+
+	// Span several chunks.
+	storeMu.Lock()
+	lib := libraries[defaultTenant]
+	for i := 5; i <= 2*exportChunkSize+50; i++ {
+		lib.books = append(lib.books, book{ID: strconv.Itoa(i), Title: "Book " + strconv.Itoa(i), Quantity: 1})
+	}
+	markStoreChanged()
+	storeMu.Unlock()
+
+	w := serve(router, http.MethodGet, "/books/export.ndjson", "")
+	expectStatus(t, w, http.StatusOK)
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/x-ndjson") {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+
+	scanner := bufio.NewScanner(w.Body)
+	count := 0
+	for scanner.Scan() {
+		var b book
+		if err := json.Unmarshal(scanner.Bytes(), &b); err != nil {
+			t.Fatalf("line %d: %v", count+1, err)
+		}
+		count++
+		if want := strconv.Itoa(count); b.ID != want {
+			t.Fatalf("line %d has book %s, want %s", count, b.ID, want)
+		}
+	}
+	if want := 2*exportChunkSize + 50; count != want {
+		t.Errorf("exported %d books, want %d", count, want)
+	}
+}
+
+func TestExportNDJSONServesByteRanges(t *testing.T) {
+	router := newTestRouter(t)
+
+	full := serve(router, http.MethodGet, "/books/export.ndjson", "")
+	expectStatus(t, full, http.StatusOK)
+	etag := full.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("ETag = %q, want a weak ETag", etag)
+	}
+
+	w := serve(router, http.MethodGet, "/books/export.ndjson", "", "Range", "bytes=10-49")
+	expectStatus(t, w, http.StatusPartialContent)
+	body := full.Body.String()
+	if got, want := w.Body.String(), body[10:50]; got != want {
+		t.Errorf("range body = %q, want %q", got, want)
+	}
+	if got, want := w.Header().Get("Content-Range"), "bytes 10-49/"+strconv.Itoa(len(body)); got != want {
+		t.Errorf("Content-Range = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("ETag"); got != etag {
+		t.Errorf("ETag of the range = %q, want %q", got, etag)
+	}
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/export.ndjson", "", "If-None-Match", etag), http.StatusNotModified)
+	expectStatus(t, serve(router, http.MethodGet, "/books/export.ndjson", "", "Range", "bytes=100000-"), http.StatusRequestedRangeNotSatisfiable)
+}
+
+func TestExportNDJSONStopsWhenClientDisconnects(t *testing.T) {
+	router := newTestRouter(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/books/export.ndjson", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Body.Len() != 0 {
+		t.Errorf("export of a disconnected client wrote %q, want nothing", w.Body.String())
+	}
+}
+
+func TestExportChangesSince(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clk := stopClock(t, start)
+	router := newTestRouter(t)
+
+	clk.now = start.Add(time.Hour)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/3", `{"quantity":31}`), http.StatusOK)
+
+	w := serve(router, http.MethodGet, "/books/export?since="+start.Add(30*time.Minute).Format(time.RFC3339), "")
+	expectStatus(t, w, http.StatusOK)
+	changes := decode[changesResponse](t, w)
+	if len(changes.Books) != 1 || changes.Books[0].ID != "3" {
+		t.Errorf("changed books = %+v, want only book 3", changes.Books)
+	}
+	if !changes.ServerTime.Equal(clk.now) {
+		t.Errorf("server_time = %s, want %s", changes.ServerTime, clk.now)
+	}
+
+	next := serve(router, http.MethodGet, "/books/export?since="+changes.ServerTime.Format(time.RFC3339), "")
+	if changes := decode[changesResponse](t, next); len(changes.Books) != 0 {
+		t.Errorf("changed books since the server time = %+v, want none", changes.Books)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/export?since=2024-03-01", ""), http.StatusBadRequest)
+}
+
+func TestExportsAreCacheable(t *testing.T) {
+	for maxAge, wantCacheControl := range map[string]string{"0": "no-cache", "300": "max-age=300"} {
+		t.Run("EXPORT_MAX_AGE="+maxAge, func(t *testing.T) {
+			t.Setenv("EXPORT_MAX_AGE", maxAge)
+			router := newTestRouter(t)
+
+			for _, path := range []string{"/books/export", "/books/export.ndjson"} {
+				w := serve(router, http.MethodGet, path, "")
+				expectStatus(t, w, http.StatusOK)
+				if got := w.Header().Get("Cache-Control"); got != wantCacheControl {
+					t.Errorf("Cache-Control of %s = %q, want %q", path, got, wantCacheControl)
+				}
+				if got := w.Header().Get("Vary"); !strings.Contains(got, "X-Tenant-ID") {
+					t.Errorf("Vary of %s = %q, want X-Tenant-ID", path, got)
+				}
+
+				etag := w.Header().Get("ETag")
+				w = serve(router, http.MethodGet, path, "", "If-None-Match", etag)
+				expectStatus(t, w, http.StatusNotModified)
+				if w.Body.Len() != 0 {
+					t.Errorf("304 of %s has body %q", path, w.Body.String())
+				}
+
+				expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"quantity":`+strconv.Itoa(len(path))+`}`), http.StatusOK)
+				w = serve(router, http.MethodGet, path, "", "If-None-Match", etag)
+				expectStatus(t, w, http.StatusOK)
+				if w.Header().Get("ETag") == etag {
+					t.Errorf("ETag of %s unchanged after a write", path)
+				}
+			}
+		})
+	}
+}
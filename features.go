@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// featureEnvPrefix is the prefix of environment variables that toggle a feature flag,
+// e.g. FEATURE_SEARCH=false disables the "search" feature.
+const featureEnvPrefix = "FEATURE_"
+
+// defaultFeatures lists the known feature flags and whether they are enabled when not configured.
+// Flags that are not listed here are disabled unless explicitly enabled.
+var defaultFeatures = map[string]bool{
+	"availability": true,
+	"batch_return": true,
+	"by_author":    true,
+	"export":       true,
+	"inventory":    true,
+	"search":       true,
+}
+
+// features holds the configured state of every feature flag, keyed by lowercase flag name.
+// It is set by loadConfig, as read by loadFeatures, and read-only afterwards.
+var features = map[string]bool{}
+
+// featureState is the state of a single feature flag as reported by getFeatures.
+type featureState struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// featureEnabled reports whether the feature flag with the given name is enabled.
+// Flags that are not configured fall back to defaultFeatures.
+func featureEnabled(name string) bool {
+	name = strings.ToLower(name)
+	if enabled, ok := features[name]; ok {
+		return enabled
+	}
+	return defaultFeatures[name]
+}
+
+// loadFeatures reads the feature flags from the JSON file at path, if path is not empty, and then
+// applies overrides, the FEATURE_<NAME> settings keyed by lowercase flag name, which take precedence
+// over the file. The file must contain a JSON object mapping flag names to booleans, e.g. {"search": false}.
+func loadFeatures(path string, overrides map[string]bool) (map[string]bool, error) {
+	flags := map[string]bool{}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading FEATURE_FLAGS_FILE: %w", err)
+		}
+
+		var fromFile map[string]bool
+		if err := json.Unmarshal(data, &fromFile); err != nil {
+			return nil, fmt.Errorf("parsing FEATURE_FLAGS_FILE: %w", err)
+		}
+		for name, enabled := range fromFile {
+			flags[strings.ToLower(name)] = enabled
+		}
+	}
+
+	for name, enabled := range overrides {
+		flags[name] = enabled
+	}
+
+	return flags, nil
+}
+
+// featureStates returns the state of every known or configured feature flag, keyed by name.
+func featureStates() map[string]bool {
+	states := make(map[string]bool, len(defaultFeatures)+len(features))
+	for name := range defaultFeatures {
+		states[name] = featureEnabled(name)
+	}
+	for name := range features {
+		states[name] = featureEnabled(name)
+	}
+	return states
+}
+
+// getFeatures returns the state of every known or configured feature flag, sorted by name.
+func getFeatures(c *gin.Context) {
+	enabled := featureStates()
+	names := make([]string, 0, len(enabled))
+	for name := range enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	states := make([]featureState, 0, len(names))
+	for _, name := range names {
+		states = append(states, featureState{Name: name, Enabled: enabled[name]})
+	}
+
+	respondJSON(c, http.StatusOK, states)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDisabledFeatureRoute404s(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books/search", `{"field":"id","op":"eq","value":"1"}`), http.StatusOK)
+
+	t.Setenv("FEATURE_SEARCH", "false")
+	router = newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books/search", `{"field":"id","op":"eq","value":"1"}`), http.StatusNotFound)
+}
+
+func TestFeatureFlagsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	if err := os.WriteFile(path, []byte(`{"Export": false, "search": false, "reservations": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ADMIN_API_KEY", "secret")
+	t.Setenv("FEATURE_FLAGS_FILE", path)
+	t.Setenv("FEATURE_SEARCH", "true")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/export", ""), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodPost, "/books/search", `{"field":"id","op":"eq","value":"1"}`), http.StatusOK)
+
+	w := serve(router, http.MethodGet, "/admin/features", "", "X-API-Key", "secret")
+	expectStatus(t, w, http.StatusOK)
+	want := []featureState{
+		{Name: "availability", Enabled: true},
+		{Name: "batch_return", Enabled: true},
+		{Name: "by_author", Enabled: true},
+		{Name: "export", Enabled: false},
+		{Name: "inventory", Enabled: true},
+		{Name: "reservations", Enabled: true},
+		{Name: "search", Enabled: true},
+	}
+	if got := decode[[]featureState](t, w); !reflect.DeepEqual(got, want) {
+		t.Errorf("features = %+v, want %+v", got, want)
+	}
+}
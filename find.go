@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFindLimit is the number of results returned by findBooks when 'limit' is not given.
+const defaultFindLimit = 20
+
+// findResult is a book matched by findBooks, together with the field it matched on.
+type findResult struct {
+	book
+	MatchField string `json:"match_field"`
+	rank       int
+}
+
+// MarshalJSON encodes the result as its book with the match_field added. Without it, the
+// book's MarshalJSON would be promoted and the match_field left out.
+func (r findResult) MarshalJSON() ([]byte, error) {
+	type plain book
+	b := plain(r.book)
+	b.AvailableForCheckout = r.availableForCheckout()
+	return json.Marshal(struct {
+		plain
+		MatchField string `json:"match_field"`
+	}{b, r.MatchField})
+}
+
+// findMatch returns how well the book matches the lowercase query q, as a rank where lower is
+// better, and the field it matched on. ok is false if the book does not match at all.
+func findMatch(b *book, q string) (rank int, field string, ok bool) {
+	title, author, isbn := strings.ToLower(b.Title), strings.ToLower(b.Author), strings.ToLower(b.ISBN)
+
+	switch {
+	case isbn == q:
+		return 0, "isbn", true
+	case strings.HasPrefix(title, q):
+		return 1, "title", true
+	case strings.HasPrefix(author, q):
+		return 2, "author", true
+	case strings.Contains(title, q):
+		return 3, "title", true
+	case strings.Contains(author, q):
+		return 4, "author", true
+	case strings.Contains(isbn, q):
+		return 5, "isbn", true
+	}
+	return 0, "", false
+}
+
+// findBooks searches the tenant's title, author, and ISBN fields at once for the 'q' query
+// parameter, case-insensitively, for patrons who type whatever they remember.
+// Matches are ranked: an exact ISBN first, then title and author prefixes, then substrings of
+// the title, author, and ISBN; books of the same rank are sorted by title. Each result names
+// the field it matched on in 'match_field'. The number of results is capped by the 'limit'
+// query parameter, an integer between 1 and maxPerPage that defaults to defaultFindLimit.
+// It returns a 400 status code if 'q' is missing.
+func findBooks(c *gin.Context) {
+	q := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	if q == "" {
+		respondError(c, http.StatusBadRequest, "missing query parameter 'q'")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultFindLimit)))
+	if err != nil || limit < 1 || limit > maxPerPage {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("limit", "an integer between 1 and "+strconv.Itoa(maxPerPage)).Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	results := []findResult{}
+	for i := range lib.books {
+		b := &lib.books[i]
+		if rank, field, ok := findMatch(b, q); ok {
+			results = append(results, findResult{book: cloneBooks(lib.books[i : i+1])[0], MatchField: field, rank: rank})
+		}
+	}
+	storeMu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := &results[i], &results[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if ta, tb := strings.ToLower(a.Title), strings.ToLower(b.Title); ta != tb {
+			return ta < tb
+		}
+		return a.ID < b.ID
+	})
+
+	respondJSON(c, http.StatusOK, results[:min(limit, len(results))])
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFindBooksRanksExactISBNFirst(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"978-0134190440 study guide"}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/3", `{"isbn":"978-0134190440-X"}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/4", `{"isbn":"978-0134190440"}`), http.StatusOK)
+
+	type match struct {
+		ID         string `json:"id"`
+		MatchField string `json:"match_field"`
+	}
+	w := serve(router, http.MethodGet, "/books/find?q=978-0134190440", "")
+	expectStatus(t, w, http.StatusOK)
+	want := []match{{"4", "isbn"}, {"5", "title"}, {"3", "isbn"}}
+	if got := decode[[]match](t, w); !reflect.DeepEqual(got, want) {
+		t.Errorf("matches = %+v, want %+v", got, want)
+	}
+
+	if got := decode[[]match](t, serve(router, http.MethodGet, "/books/find?q=MR.+GO&limit=1", "")); len(got) != 1 || got[0].MatchField != "author" {
+		t.Errorf("limited author matches = %+v, want a single author match", got)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/find", ""), http.StatusBadRequest)
+}
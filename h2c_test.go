@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func TestServerHandlerH2C(t *testing.T) {
+	t.Setenv("ENABLE_H2C", "true")
+	srv := httptest.NewServer(serverHandler(newTestRouter(t)))
+	defer srv.Close()
+
+	// A prior-knowledge h2c client: HTTP/2 framing over a plain TCP connection.
+	h2 := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+	for _, tc := range []struct {
+		client *http.Client
+		proto  string
+	}{
+		{h2, "HTTP/2.0"},
+		{srv.Client(), "HTTP/1.1"},
+	} {
+		resp, err := tc.client.Get(srv.URL + "/books/1")
+		if err != nil {
+			t.Fatalf("GET over %s: %v", tc.proto, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Proto != tc.proto {
+			t.Errorf("response = %d over %s, want 200 over %s", resp.StatusCode, resp.Proto, tc.proto)
+		}
+	}
+}
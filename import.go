@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// importSummary is the response of a successful importBooks.
+type importSummary struct {
+	Imported int    `json:"imported"`
+	Merged   int    `json:"merged"`
+	Books    []book `json:"books"`
+}
+
+// importBooks creates several books at once from a dataset.
+// It expects a JSON array in the request body whose entries have the format accepted by createBook.
+//
+// Entries sharing an ISBN are rejected with a 400 status code, unless merging duplicates is
+// configured: then they are merged into a single book, keeping the first entry's details and
+// summing the quantities (and copies) of all of them. Entries without an ISBN are never merged.
+// The import is atomic: if any entry is invalid, nothing is imported. Every entry needs an ID that
+// no other entry uses, or it is rejected with a 400 status code. An ID already used by a stored book
+// is rejected with a 409 status code, and so is an ISBN already used by a stored book if unique ISBNs
+// are enforced, and a title and author pair already used by a stored book if unique pairs are enforced.
+// It returns the imported books together with the number of entries that were merged away
+// with status code 201 (Created).
+// A multipart form upload is imported as CSV instead, as described by importBooksCSV.
+func importBooks(c *gin.Context) {
+	if c.ContentType() == "multipart/form-data" {
+		importBooksCSV(c)
+		return
+	}
+
+	var entries []createBookRequest
+
+	if err := bindBookJSON(c, &entries); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := serverClock.Now()
+	books := make([]book, 0, len(entries))
+	byISBN := make(map[string]int, len(entries))
+	merged := 0
+
+	for _, entry := range entries {
+		b, err := entry.newBook(now)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		i, dup := byISBN[b.ISBN]
+		if b.ISBN == "" || !dup {
+			if b.ISBN != "" {
+				byISBN[b.ISBN] = len(books)
+			}
+			books = append(books, b)
+			continue
+		}
+
+		if !importMergeDuplicates {
+			respondError(c, http.StatusBadRequest, "duplicate ISBN '"+b.ISBN+"' in import")
+			return
+		}
+		if err := mergeBooks(&books[i], b); err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		merged++
+	}
+
+	ids := make(map[string]bool, len(books))
+	for _, b := range books {
+		if ids[b.ID] {
+			respondError(c, http.StatusBadRequest, "duplicate book ID '"+b.ID+"' in import")
+			return
+		}
+		ids[b.ID] = true
+
+		if !checkMaxQuantity(c, b.Quantity) {
+			return
+		}
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	for _, b := range books {
+		if !checkIDAvailable(c, lib, b.ID) || !checkISBNAvailable(c, lib, b.ISBN, "") || !checkTitleAuthorAvailable(c, lib, b.Title, b.Author, "") {
+			return
+		}
+	}
+
+	lib.books = append(lib.books, books...)
+	if len(books) > 0 {
+		markStoreChanged()
+	}
+	respondJSON(c, http.StatusCreated, importSummary{Imported: len(books), Merged: merged, Books: books})
+}
+
+// mergeBooks merges the duplicate dup into b by adding its quantity and copies to b's.
+// It returns an error if only one of them tracks copies, since their quantities cannot be combined.
+func mergeBooks(b *book, dup book) error {
+	if b.tracksCopies() != dup.tracksCopies() {
+		return fmt.Errorf("cannot merge books with ISBN '%s' that do not both track copies", b.ISBN)
+	}
+
+	if !b.tracksCopies() {
+		b.Quantity += dup.Quantity
+		return nil
+	}
+
+	b.Copies = append(b.Copies, dup.Copies...)
+	if err := b.validateCopies(); err != nil {
+		return fmt.Errorf("cannot merge books with ISBN '%s': %w", b.ISBN, err)
+	}
+	b.Quantity = b.availableCopies()
+	return nil
+}
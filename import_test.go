@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestImportBooksRequiresUniqueIDs(t *testing.T) {
+	router := newTestRouter(t)
+
+	for name, tc := range map[string]struct {
+		body   string
+		status int
+	}{
+		"missing":   {`[{"id":"5","title":"a"},{"title":"b"}]`, http.StatusBadRequest},
+		"repeated":  {`[{"id":"5","title":"a"},{"id":"5","title":"b"}]`, http.StatusBadRequest},
+		"in use":    {`[{"id":"5","title":"a"},{"id":"2","title":"b"}]`, http.StatusConflict},
+		"all fresh": {`[{"id":"5","title":"a"},{"id":"6","title":"b"}]`, http.StatusCreated},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resetStore()
+			expectStatus(t, serve(router, http.MethodPost, "/books/import", tc.body), tc.status)
+			if tc.status != http.StatusCreated {
+				expectStatus(t, serve(router, http.MethodGet, "/books/5", ""), http.StatusNotFound)
+			}
+		})
+	}
+}
+
+func TestImportBooksMergesDuplicateISBNs(t *testing.T) {
+	body := `[
+		{"id":"5","title":"Go in Action","isbn":"978-1617291784","quantity":2},
+		{"id":"6","title":"Go in action (2nd copy)","isbn":"978-1617291784","quantity":3},
+		{"id":"7","title":"Other","quantity":1}
+	]`
+
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books/import", body), http.StatusBadRequest)
+
+	t.Setenv("IMPORT_MERGE_DUPLICATES", "true")
+	router = newTestRouter(t)
+	w := serve(router, http.MethodPost, "/books/import", body)
+	expectStatus(t, w, http.StatusCreated)
+	if summary := decode[importSummary](t, w); summary.Imported != 2 || summary.Merged != 1 {
+		t.Errorf("summary = %d imported and %d merged, want 2 and 1", summary.Imported, summary.Merged)
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); b.Title != "Go in Action" || b.Quantity != 5 {
+		t.Errorf("merged book = %q with quantity %d, want the first entry's title with quantity 5", b.Title, b.Quantity)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/6", ""), http.StatusNotFound)
+}
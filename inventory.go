@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inventoryItem is a single entry of the external inventory feed.
+type inventoryItem struct {
+	ID       string   `json:"id" binding:"required"`
+	Quantity quantity `json:"quantity" binding:"min=0"`
+}
+
+// inventoryReport summarises the result of reconciling the store against an inventory feed.
+// Unmatched lists the books in the feed that failed the precondition, and is left out if there are none.
+// Matched is the number of books in the feed that were eligible for an update, and Modified the
+// number of them whose quantity actually changed.
+type inventoryReport struct {
+	Updated         []string `json:"updated"`
+	MissingLocally  []string `json:"missing_locally"`
+	MissingFromFeed []string `json:"missing_from_feed"`
+	Skipped         []string `json:"skipped"`
+	Unmatched       []string `json:"unmatched,omitempty"`
+	Matched         int      `json:"matched"`
+	Modified        int      `json:"modified"`
+}
+
+// syncInventory reconciles the tenant's books against an authoritative inventory feed.
+// It expects a JSON array in the request body with the following format:
+//
+//	[
+//	  {"id": "string", "quantity": "int"}
+//	]
+//
+// Quantities of books present in both the feed and the store are overwritten by the feed.
+// IDs that only appear in the feed are reported but not created, and books that are
+// missing from the feed are reported but left untouched. Books that track individual copies
+// derive their quantity from the copies, so they are reported as skipped and left untouched too.
+// The optional 'where' query parameter restricts the update to books matching a precondition of the
+// form "field:op:value", as described by parsePrecondition; e.g. 'where=quantity:eq:0' only restocks
+// books that are out of stock. Books in the feed that fail it are reported as unmatched and left untouched.
+// A feed with duplicate IDs or a quantity above the configured maximum, or an invalid precondition,
+// is rejected with a 400 status code. A feed that would take a book's quantity below its reserved
+// quantity is rejected with a 422 status code and the result of each such book; nothing is updated then.
+// It returns the reconciliation report with status code 200 (OK).
+func syncInventory(c *gin.Context) {
+	var feed []inventoryItem
+
+	precondition := func(*book) bool { return true }
+	if where, ok := c.GetQuery("where"); ok {
+		var err error
+		if precondition, err = parsePrecondition(where); err != nil {
+			respondError(c, http.StatusBadRequest, "invalid query parameter 'where': "+err.Error())
+			return
+		}
+	}
+
+	if err := c.ShouldBindJSON(&feed); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quantities := make(map[string]quantity, len(feed))
+	for _, item := range feed {
+		if _, dup := quantities[item.ID]; dup {
+			respondError(c, http.StatusBadRequest, "duplicate id '"+item.ID+"' in inventory feed")
+			return
+		}
+		if !checkMaxQuantity(c, item.Quantity) {
+			return
+		}
+		quantities[item.ID] = item.Quantity
+	}
+
+	lib := currentLibrary(c)
+
+	now := serverClock.Now()
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	report := inventoryReport{
+		Updated:         []string{},
+		MissingLocally:  []string{},
+		MissingFromFeed: []string{},
+		Skipped:         []string{},
+	}
+
+	known := make(map[string]bool, len(lib.books))
+	var updates []*book
+	var rejected []batchItemResult
+	for i := range lib.books {
+		b := &lib.books[i]
+		known[b.ID] = true
+
+		quantity, ok := quantities[b.ID]
+		if !ok {
+			report.MissingFromFeed = append(report.MissingFromFeed, b.ID)
+			continue
+		}
+
+		if b.tracksCopies() {
+			report.Skipped = append(report.Skipped, b.ID)
+			continue
+		}
+
+		if !precondition(b) {
+			report.Unmatched = append(report.Unmatched, b.ID)
+			continue
+		}
+		report.Matched++
+
+		updated := *b
+		updated.Quantity = quantity
+		if err := updated.validateReserved(); err != nil {
+			rejected = append(rejected, batchItemResult{ID: b.ID, Error: err.Error()})
+			continue
+		}
+
+		if b.Quantity != quantity {
+			updates = append(updates, b)
+			report.Modified++
+		}
+		report.Updated = append(report.Updated, b.ID)
+	}
+
+	if len(rejected) > 0 {
+		respondErrorDetails(c, http.StatusUnprocessableEntity, "no quantities were updated, some would fall below the reserved quantity", errorDetails{Results: rejected})
+		return
+	}
+	for _, b := range updates {
+		b.Quantity = quantities[b.ID]
+		b.touch(now)
+	}
+	if len(updates) > 0 {
+		markStoreChanged()
+	}
+
+	for _, item := range feed {
+		if !known[item.ID] {
+			report.MissingLocally = append(report.MissingLocally, item.ID)
+		}
+	}
+
+	respondJSON(c, http.StatusOK, report)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSyncInventoryKeepsReservedCopies(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Popular","quantity":5,"reserved_quantity":4}`), http.StatusCreated)
+
+	w := serve(router, http.MethodPut, "/books/inventory", `[{"id":"2","quantity":7},{"id":"5","quantity":1}]`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+	if results := decode[errorResponse](t, w).Results; len(results) != 1 || results[0].ID != "5" {
+		t.Errorf("results = %+v, want book 5 rejected", results)
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); b.Quantity != 5 {
+		t.Errorf("quantity of book 5 = %d, want 5", b.Quantity)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); b.Quantity != 20 {
+		t.Errorf("quantity of book 2 = %d, want it left at 20", b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPut, "/books/inventory", `[{"id":"5","quantity":4}]`), http.StatusOK)
+}
+
+func TestSyncInventoryReconcilesFeed(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPut, "/books/inventory", `[{"id":"1","quantity":9},{"id":"2","quantity":20},{"id":"99","quantity":1}]`)
+	expectStatus(t, w, http.StatusOK)
+	report := decode[inventoryReport](t, w)
+	if !reflect.DeepEqual(report.Updated, []string{"1", "2"}) || report.Modified != 1 {
+		t.Errorf("updated = %v with %d modified, want books 1 and 2 with 1 modified", report.Updated, report.Modified)
+	}
+	if !reflect.DeepEqual(report.MissingLocally, []string{"99"}) {
+		t.Errorf("missing_locally = %v, want [99]", report.MissingLocally)
+	}
+	if !reflect.DeepEqual(report.MissingFromFeed, []string{"3", "4"}) {
+		t.Errorf("missing_from_feed = %v, want [3 4]", report.MissingFromFeed)
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 9 {
+		t.Errorf("quantity of book 1 = %d, want 9", b.Quantity)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/99", ""), http.StatusNotFound)
+}
+
+func TestSyncInventoryWherePrecondition(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"quantity":0}`), http.StatusOK)
+
+	w := serve(router, http.MethodPut, "/books/inventory?where=quantity:eq:0", `[{"id":"1","quantity":5},{"id":"2","quantity":5},{"id":"3","quantity":5}]`)
+	expectStatus(t, w, http.StatusOK)
+	report := decode[inventoryReport](t, w)
+	if report.Matched != 1 || report.Modified != 1 || !reflect.DeepEqual(report.Unmatched, []string{"2", "3"}) {
+		t.Errorf("report = %+v, want book 1 matched and modified, books 2 and 3 unmatched", report)
+	}
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/1", "")); b.Quantity != 5 {
+		t.Errorf("quantity of out-of-stock book 1 = %d, want it restocked to 5", b.Quantity)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); b.Quantity != 20 {
+		t.Errorf("quantity of in-stock book 2 = %d, want it left at 20", b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPut, "/books/inventory?where=quantity:near:0", `[{"id":"1","quantity":5}]`), http.StatusBadRequest)
+}
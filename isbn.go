@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isbnRequest is the JSON payload of checkoutByISBN and returnByISBN.
+type isbnRequest struct {
+	ISBN string `json:"isbn" binding:"required"`
+	User string `json:"user"`
+}
+
+// checkoutByISBN checks out a book identified by its ISBN rather than its internal ID,
+// which is what barcode scanners read.
+// It expects a JSON payload in the request body with the following format:
+//
+//	{
+//	  "isbn": "string",
+//	  "user": "string"
+//	}
+//
+// If several books share the ISBN, the one with the highest quantity is checked out.
+// It returns the checked out book together with its due date, a 404 status code if no book
+// has the ISBN, or a 422 status code if every book with the ISBN is out of stock.
+func checkoutByISBN(c *gin.Context) {
+	var req isbnRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	checkoutBestMatch(c, "with ISBN '"+req.ISBN+"'", req.User, func(b *book) bool {
+		return b.ISBN == req.ISBN
+	})
+}
+
+// returnByISBN returns a book identified by its ISBN rather than its internal ID.
+// It expects the same JSON payload as checkoutByISBN. The oldest outstanding checkout of a book
+// with the ISBN is cleared, held by the user if one is given, and the book's quantity is incremented.
+// It returns the returned book, a 404 status code if no book has the ISBN, or a 422 status code
+// if no book with the ISBN is checked out (by the user).
+func returnByISBN(c *gin.Context) {
+	var req isbnRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	matches := map[string]*book{}
+	for i := range lib.books {
+		if b := &lib.books[i]; b.ISBN == req.ISBN {
+			matches[b.ID] = b
+		}
+	}
+
+	if len(matches) == 0 {
+		respondError(c, http.StatusNotFound, "no book with ISBN '"+req.ISBN+"'")
+		return
+	}
+
+	for _, co := range lib.checkouts {
+		book, ok := matches[co.BookID]
+		if !ok || (req.User != "" && co.User != req.User) {
+			continue
+		}
+
+		co, _ = lib.clearCheckout(co.BookID, req.User)
+		book.returnCopy(co.Barcode)
+		book.touch(serverClock.Now())
+		markStoreChanged()
+		respondJSON(c, http.StatusOK, book)
+		return
+	}
+
+	if req.User != "" {
+		respondError(c, http.StatusUnprocessableEntity, "no book with ISBN '"+req.ISBN+"' is checked out by this user")
+		return
+	}
+	respondError(c, http.StatusUnprocessableEntity, "no book with ISBN '"+req.ISBN+"' has outstanding checkouts")
+}
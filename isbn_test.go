@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckoutAndReturnByISBN(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/3", `{"isbn":"978-0134190440"}`), http.StatusOK)
+
+	w := serve(router, http.MethodPost, "/checkout/by-isbn", `{"isbn":"978-0134190440","user":"ann"}`)
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[checkoutResponse](t, w).Data; b.ID != "3" || b.Quantity != 29 {
+		t.Errorf("checked out book %s with quantity %d left, want book 3 with 29", b.ID, b.Quantity)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/return/by-isbn", `{"isbn":"978-0134190440","user":"bob"}`), http.StatusUnprocessableEntity)
+	w = serve(router, http.MethodPost, "/return/by-isbn", `{"isbn":"978-0134190440","user":"ann"}`)
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.ID != "3" || b.Quantity != 30 {
+		t.Errorf("returned book %s with quantity %d, want book 3 with 30", b.ID, b.Quantity)
+	}
+	if got := checkoutUsers(t, router, "3"); len(got) != 0 {
+		t.Errorf("checkouts = %v after the return, want none", got)
+	}
+	expectStatus(t, serve(router, http.MethodPost, "/return/by-isbn", `{"isbn":"978-0134190440"}`), http.StatusUnprocessableEntity)
+
+	expectStatus(t, serve(router, http.MethodPost, "/checkout/by-isbn", `{"isbn":"978-1617291784"}`), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodPost, "/return/by-isbn", `{"isbn":"978-1617291784"}`), http.StatusNotFound)
+}
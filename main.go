@@ -1,140 +1,876 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/sync/singleflight"
 )
 
-// book represents a book with its ID, title, author, and quantity.
+// book represents a book with its ID, title, author, ISBN, optional category and cover image URL,
+// and quantity, along with when it was created and last modified.
+// LoanDays optionally overrides the default loan period for the book; 0 means the default applies.
+// A book may list its individual physical Copies, in which case its quantity is the number of available copies.
+// ReservedQuantity copies are kept for in-library use and cannot be checked out; AvailableForCheckout is
+// the number of copies that can, and is only set when the book is encoded.
+// BorrowCount is the number of times the book has been checked out; it is maintained by the server.
 type book struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Author   string `json:"author"`
-	Quantity int    `json:"quantity"`
+	ID                   string     `json:"id"`
+	Title                string     `json:"title"`
+	Author               string     `json:"author"`
+	ISBN                 string     `json:"isbn"`
+	Category             string     `json:"category,omitempty"`
+	Tags                 []string   `json:"tags,omitempty" binding:"omitempty,unique,dive,required"`
+	CoverURL             string     `json:"cover_url,omitempty"`
+	Quantity             quantity   `json:"quantity" binding:"min=0"`
+	ReservedQuantity     quantity   `json:"reserved_quantity,omitempty" binding:"min=0"`
+	MinQuantity          quantity   `json:"min_quantity,omitempty" binding:"min=0"`
+	AvailableForCheckout quantity   `json:"available_for_checkout"`
+	LoanDays             int        `json:"loan_days,omitempty" binding:"omitempty,min=1"`
+	AvailableFrom        string     `json:"available_from,omitempty"`
+	AvailableUntil       string     `json:"available_until,omitempty"`
+	Copies               []bookCopy `json:"copies,omitempty" binding:"omitempty,dive"`
+	BorrowCount          int        `json:"borrow_count"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
 }
 
-var books = []book{
+// loanPeriod returns how long the book may be kept after checkout.
+func (b *book) loanPeriod() time.Duration {
+	if b.LoanDays > 0 {
+		return time.Duration(b.LoanDays) * 24 * time.Hour
+	}
+	return loanPeriod
+}
+
+// validateCoverURL returns an error unless coverURL is empty or an absolute http or https URL with a host.
+func validateCoverURL(coverURL string) error {
+	if coverURL == "" {
+		return nil
+	}
+	u, err := url.Parse(coverURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid cover_url '%s': must be an absolute http or https URL", coverURL)
+	}
+	return nil
+}
+
+// touch records that the book was modified at now.
+func (b *book) touch(now time.Time) {
+	b.UpdatedAt = now
+}
+
+// seedBooks are the books the default tenant's library starts out with.
+var seedBooks = []book{
 	{ID: "1", Title: "Golang pointers", Author: "Mr. Golang", Quantity: 2},
 	{ID: "2", Title: "Goroutines", Author: "Mr. Goroutine", Quantity: 20},
 	{ID: "3", Title: "Golang routers", Author: "Mr. Router", Quantity: 30},
 	{ID: "4", Title: "Golang concurrency", Author: "Mr. Currency", Quantity: 40},
 }
 
-// getBooks returns a list of all books.
+// listGroup coalesces concurrent identical getBooks requests so they share a single read and serialization.
+var listGroup singleflight.Group
+
+// getBooks returns a list of all books of the tenant, narrowed down by the filters described in filterBooks.
 // It takes a pointer to a gin.Context object as its only parameter.
-// It uses the IndentedJSON method of the gin.Context object to send an HTTP response with the list of books in JSON format.
+// The list is ordered by the 'sort' query parameter (e.g. 'title:asc'), falling back to the configured
+// default sort, and to insertion order if neither is set.
+// Concurrent requests of a tenant with the same (normalized) query string are coalesced, so only one of them
+// reads the books and serializes the JSON response that all of them send.
+// The response is cached with an ETag until the store is next modified, so repeated requests are served
+// without reading the store, and a request whose If-None-Match header matches receives an empty 304 (Not Modified).
+// With 'expand=checkouts', every book carries its outstanding checkouts.
+// If emptyAs204 is set and no book matches, it responds with an empty 204 (No Content) instead of an empty list.
 func getBooks(c *gin.Context) {
-	c.IndentedJSON(http.StatusOK, books)
+	spec := defaultSort
+	if s, ok := c.GetQuery("sort"); ok {
+		var err error
+		if spec, err = parseSort(s); err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	expand, err := expandCheckouts(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pretty := prettyPrint(c)
+	key := fmt.Sprintf("%s?%s#pretty=%t", currentTenant(c), c.Request.URL.Query().Encode(), pretty)
+	generation := storeGeneration.Load()
+
+	entry, ok := listCache.get(key, generation)
+	if !ok {
+		v, err, _ := listGroup.Do(key, func() (interface{}, error) {
+			books := filterBooks(c)
+			sortBooks(books, spec)
+
+			var result any = books
+			if expand {
+				lib := currentLibrary(c)
+				storeMu.RLock()
+				result = lib.withCheckouts(books)
+				storeMu.RUnlock()
+			}
+			body, err := marshalJSON(result, pretty)
+			if err != nil {
+				return nil, err
+			}
+			return listCache.put(key, generation, body, len(books) == 0), nil
+		})
+
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "failed to list books")
+			return
+		}
+		entry = v.(listCacheEntry)
+	}
+
+	if emptyAs204 && entry.empty {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.Header("ETag", entry.etag)
+	if etagMatches(c, entry.etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, jsonContentType(), entry.body)
+}
+
+// filterBooks returns the tenant's books matching the optional search query parameters of the request:
+//
+//	author - the author's name, matched case-insensitively
+//	title  - a case-insensitive substring of the title
+//
+// Without any of these parameters, it returns all books. The books are copies that may be used
+// after the store is unlocked.
+func filterBooks(c *gin.Context) []book {
+	author := c.Query("author")
+	title := strings.ToLower(c.Query("title"))
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	result := []book{}
+	for _, b := range lib.books {
+		if author != "" && !strings.EqualFold(b.Author, author) {
+			continue
+		}
+		if title != "" && !strings.Contains(strings.ToLower(b.Title), title) {
+			continue
+		}
+		result = append(result, b)
+	}
+	return cloneBooks(result)
+}
+
+// errInvalidQuery returns an error describing a query parameter whose value is not the expected kind.
+func errInvalidQuery(name, expected string) error {
+	return fmt.Errorf("query parameter '%s' must be %s", name, expected)
+}
+
+// deleteResponse is the response of a successful bulk delete.
+type deleteResponse struct {
+	Message string `json:"message"`
+	Deleted int    `json:"deleted"`
+}
+
+// deleteBooksByAuthor deletes every book written by the author given in the 'author' query parameter.
+// The author must match exactly, and the request must also carry 'confirm=true' to guard against accidents.
+// It returns the number of deleted books.
+// If the 'author' query parameter is missing, it returns a 400 status code rather than deleting everything,
+// and if any of the author's books is checked out, it deletes nothing and returns a 409 status code,
+// so that no checkout is left referring to a book that no longer exists.
+func deleteBooksByAuthor(c *gin.Context) {
+	author := c.Query("author")
+
+	if author == "" {
+		respondError(c, http.StatusBadRequest, "missing query parameter 'author'")
+		return
+	}
+
+	if c.Query("confirm") != "true" {
+		respondError(c, http.StatusBadRequest, "deleting books requires query parameter 'confirm=true'")
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	var checkedOut []string
+	for _, b := range lib.books {
+		if b.Author == author && lib.isCheckedOut(b.ID) {
+			checkedOut = append(checkedOut, b.ID)
+		}
+	}
+	if len(checkedOut) > 0 {
+		respondError(c, http.StatusConflict, "no books were deleted, some are checked out: "+strings.Join(checkedOut, ", "))
+		return
+	}
+
+	kept := lib.books[:0]
+	for _, b := range lib.books {
+		if b.Author != author {
+			kept = append(kept, b)
+		}
+	}
+	deleted := len(lib.books) - len(kept)
+	lib.books = kept
+	if deleted > 0 {
+		markStoreChanged()
+	}
+
+	respondJSON(c, http.StatusOK, deleteResponse{Message: "success", Deleted: deleted})
 }
 
-// createBook creates a new book and appends it to the books slice.
+// bookAvailability is the lightweight representation of a book used by getAvailability.
+type bookAvailability struct {
+	ID        string   `json:"id"`
+	Available bool     `json:"available"`
+	Quantity  quantity `json:"quantity"`
+}
+
+// getAvailability returns only the ID and availability of each book, which is cheaper to
+// generate and transfer than the full records returned by getBooks.
+// It accepts the same search query parameters as getBooks.
+func getAvailability(c *gin.Context) {
+	matches := filterBooks(c)
+
+	result := make([]bookAvailability, 0, len(matches))
+	for _, b := range matches {
+		result = append(result, bookAvailability{ID: b.ID, Available: b.Quantity > 0, Quantity: b.Quantity})
+	}
+
+	respondJSON(c, http.StatusOK, result)
+}
+
+// createBookRequest is the JSON payload of createBook. Author and Quantity shadow the book's fields
+// as pointers, so that an omitted author or quantity can be told apart from an explicit "" or 0.
+type createBookRequest struct {
+	book
+	Author   *string   `json:"author" binding:"omitempty,min=1"`
+	Quantity *quantity `json:"quantity" binding:"omitempty,min=0"`
+}
+
+// newBook returns the book described by the request as it is to be stored, created at now.
+// An omitted author or quantity is replaced by the configured default, the quantity of a book with copies
+// is the number of its available copies, and the borrow count starts at 0.
+// It returns an error if the book has no ID or two copies share a barcode.
+func (r createBookRequest) newBook(now time.Time) (book, error) {
+	b := r.book
+	if b.ID == "" {
+		return book{}, errors.New("missing book ID")
+	}
+	b.BorrowCount = 0
+	b.Author = defaultAuthor
+	if r.Author != nil {
+		b.Author = *r.Author
+	}
+	b.Quantity = defaultQuantity
+	if r.Quantity != nil {
+		b.Quantity = *r.Quantity
+	}
+
+	if err := b.validateCopies(); err != nil {
+		return book{}, err
+	}
+	if err := validateCoverURL(b.CoverURL); err != nil {
+		return book{}, err
+	}
+	if err := b.validateWindow(); err != nil {
+		return book{}, err
+	}
+	if b.tracksCopies() {
+		b.Quantity = b.availableCopies()
+	}
+	if err := b.validateReserved(); err != nil {
+		return book{}, err
+	}
+
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	return b, nil
+}
+
+// createBook creates a new book and appends it to the tenant's books.
 // It expects a JSON payload in the request body with the following format:
 //
 //	{
 //	  "title": "string",
 //	  "author": "string",
+//	  "isbn": "string",
+//	  "category": "string",
+//	  "tags": ["string"],
+//	  "cover_url": "string",
 //	  "quantity": "int",
+//	  "loan_days": "int",
+//	  "available_from": "HH:MM",
+//	  "available_until": "HH:MM",
+//	  "copies": [{"barcode": "string", "available": "bool"}],
 //	  "id": "string"
 //	}
 //
-// It returns the newly created book as a JSON response with status code 201 (Created).
+// The quantity may also be sent as a numeric string or a whole floating point number.
+// If it is omitted, the configured default quantity is used; an explicit 0 is kept.
+// Likewise, an omitted author is replaced by the configured default author, but an explicitly
+// empty author is rejected.
+// If copies are listed, the quantity is ignored and set to the number of available copies;
+// copies without "available" are available.
+// It returns the newly created book as a JSON response with status code 201 (Created),
+// a 400 status code with the reason if the payload cannot be decoded, the ID is missing, or the quantity
+// exceeds the configured maximum, or a 409 status code if the ID is already used by another book,
+// or unique ISBNs or title and author pairs are enforced and already used by another book.
 func createBook(c *gin.Context) {
-	var newBook book
+	var input createBookRequest
+
+	if err := bindBookJSON(c, &input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	newBook, err := input.newBook(serverClock.Now())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !checkMaxQuantity(c, newBook.Quantity) {
+		return
+	}
+
+	lib := currentLibrary(c)
 
-	if err := c.BindJSON(&newBook); err != nil {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	if !checkIDAvailable(c, lib, newBook.ID) || !checkISBNAvailable(c, lib, newBook.ISBN, "") || !checkTitleAuthorAvailable(c, lib, newBook.Title, newBook.Author, "") {
 		return
 	}
 
-	books = append(books, newBook)
-	c.IndentedJSON(http.StatusCreated, newBook)
+	lib.books = append(lib.books, newBook)
+	markStoreChanged()
+	respondJSON(c, http.StatusCreated, newBook)
 }
 
 // bookById handles GET requests for a single book by ID.
+// The response carries a Last-Modified header, and a request with an If-Modified-Since header
+// receives an empty 304 (Not Modified) response if the book has not changed since.
+// If the book is not found and the request carries 'suggest=true', the 404 response
+// includes up to 3 suggestions of books the client might have meant.
+// With 'expand=checkouts', the book carries its outstanding checkouts.
 func bookById(c *gin.Context) {
+	expand, err := expandCheckouts(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	id := c.Param("id")
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
 
-	book, err := getBookById(id)
+	book, err := lib.getBookById(id)
 
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": "Book not found"})
+		if c.Query("suggest") == "true" {
+			suggestions := lib.suggestBooks(id, 3)
+			respondErrorDetails(c, http.StatusNotFound, "Book not found", errorDetails{Suggestions: &suggestions})
+			return
+		}
+		respondError(c, http.StatusNotFound, "Book not found")
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, book)
+	setLastModified(c, book)
+	if !checkModifiedSince(c, book) {
+		return
+	}
+
+	if expand {
+		respondJSON(c, http.StatusOK, expandedBook{book: *book, Checkouts: lib.checkoutsOf(book.ID)})
+		return
+	}
+	respondJSON(c, http.StatusOK, book)
+}
+
+// checkIDAvailable reports whether a new book may be given the ID id. If another book already
+// uses it, it responds with status code 409 (Conflict) and returns false.
+// Callers must hold storeMu.
+func checkIDAvailable(c *gin.Context, lib *library, id string) bool {
+	if _, err := lib.getBookById(id); err == nil {
+		respondError(c, http.StatusConflict, "book ID '"+id+"' is already in use")
+		return false
+	}
+	return true
+}
+
+// checkISBNAvailable reports whether isbn may be given to the book with the ID exceptID
+// (empty for a new book). If unique ISBNs are enforced and another book already uses it,
+// it responds with status code 409 (Conflict) and returns false. Empty ISBNs never conflict.
+// Callers must hold storeMu.
+func checkISBNAvailable(c *gin.Context, lib *library, isbn, exceptID string) bool {
+	if !uniqueISBN || isbn == "" {
+		return true
+	}
+
+	for _, b := range lib.books {
+		if b.ISBN == isbn && b.ID != exceptID {
+			respondError(c, http.StatusConflict, "ISBN '"+isbn+"' is already used by book '"+b.ID+"'")
+			return false
+		}
+	}
+	return true
+}
+
+// checkTitleAuthorAvailable reports whether the book with the ID exceptID (empty for a new book)
+// may have the given title and author. If unique title and author pairs are enforced and another
+// book already has both, it responds with status code 409 (Conflict) and returns false.
+// Callers must hold storeMu.
+func checkTitleAuthorAvailable(c *gin.Context, lib *library, title, author, exceptID string) bool {
+	if err := lib.titleAuthorConflict(title, author, exceptID); err != nil {
+		respondError(c, http.StatusConflict, err.Error())
+		return false
+	}
+	return true
+}
+
+// titleAuthorConflict returns an error if unique title and author pairs are enforced and a book
+// other than the one with the ID exceptID already has the given title and author.
+// Callers must hold storeMu.
+func (l *library) titleAuthorConflict(title, author, exceptID string) error {
+	if !uniqueTitleAuthor {
+		return nil
+	}
+
+	for _, b := range l.books {
+		if b.Title == title && b.Author == author && b.ID != exceptID {
+			return fmt.Errorf("a book titled '%s' by '%s' already exists: '%s'", title, author, b.ID)
+		}
+	}
+	return nil
 }
 
 // getBookById returns a pointer to a book and an error. It takes a string id as input.
-// It searches for a book in the books slice with the given id and returns a pointer to the book if found.
+// It searches for a book in the library with the given id and returns a pointer to the book if found.
 // If the book is not found, it returns nil and an error.
-func getBookById(id string) (*book, error) {
-	for i, b := range books {
+// Callers must hold storeMu.
+func (l *library) getBookById(id string) (*book, error) {
+	for i, b := range l.books {
 		if b.ID == id {
-			return &books[i], nil
+			return &l.books[i], nil
 		}
 	}
 	return nil, errors.New("book not found")
 }
 
+// checkoutResponse is the response of a successful checkout.
+type checkoutResponse struct {
+	Message string    `json:"message"`
+	Data    *book     `json:"data"`
+	DueAt   time.Time `json:"due_at"`
+}
+
 // checkoutBook is a handler function that checks out a book by its ID.
-// It retrieves the book by ID, decrements its quantity by 1, and returns the updated book.
-// If the book is not found or its quantity is 0, it returns an error message.
+// It retrieves the book by ID, decrements its quantity by 1, records the checkout for the
+// optional 'user' query parameter, and returns the updated book together with its due date.
+// For a book that tracks copies, the optional 'barcode' query parameter selects the copy to
+// check out; without it, the first available copy is checked out.
+// If the book or copy is not found it returns a 404 status code, if it is outside its availability
+// window a 403 status code, if it is not available a 422 status code, and if the checkout would take
+// its quantity below its minimum quantity a 409 status code.
 func checkoutBook(c *gin.Context) {
 	id, ok := c.GetQuery("id")
 
 	if !ok {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "missing query parameter 'id' "})
+		respondError(c, http.StatusBadRequest, "missing query parameter 'id' ")
 		return
 	}
 
-	book, err := getBookById(id)
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	book, err := lib.getBookById(id)
 
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": "book not found"})
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+
+	now := serverClock.Now()
+	if !checkWindow(c, book, now) {
+		return
+	}
+
+	if book.availableForCheckout() <= 0 {
+		respondError(c, http.StatusUnprocessableEntity, "book is not available at the moment, check in again later")
+		return
+	}
+	if book.atFloor() {
+		respondError(c, http.StatusConflict, fmt.Sprintf("book cannot be checked out below its minimum quantity of %d", book.MinQuantity))
+		return
+	}
+
+	barcode, err := book.takeCopy(c.Query("barcode"))
+	if errors.Is(err, errCopyNotFound) {
+		respondError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	book.touch(now)
+	co := lib.recordCheckout(book, c.Query("user"), barcode)
+	markStoreChanged()
+
+	respondJSON(c, http.StatusOK, checkoutResponse{Message: "success", Data: book, DueAt: co.DueAt})
+}
+
+// checkoutByTitleRequest is the JSON payload of checkoutByTitle.
+type checkoutByTitleRequest struct {
+	Title string `json:"title" binding:"required"`
+	User  string `json:"user"`
+}
+
+// checkoutByTitle checks out any available book whose title matches the given one case-insensitively,
+// for patrons who want any copy of a title rather than a specific book.
+// It expects a JSON payload in the request body with the following format:
+//
+//	{
+//	  "title": "string",
+//	  "user": "string"
+//	}
+//
+// If several books match, the one with the highest quantity is checked out.
+// It returns the checked out book together with its due date, a 404 status code if no book
+// matches the title, or a 422 status code if every matching book is out of stock.
+func checkoutByTitle(c *gin.Context) {
+	var req checkoutByTitleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	checkoutBestMatch(c, "titled '"+req.Title+"'", req.User, func(b *book) bool {
+		return strings.EqualFold(b.Title, req.Title)
+	})
+}
+
+// checkoutBestMatch checks out, for user, the book with the most copies available for checkout among the tenant's
+// books for which match returns true, and responds with the book and its due date.
+// Books outside their availability window or at their minimum quantity are passed over.
+// It responds with a 404 status code if no book matches, a 403 status code if every matching book
+// in stock is outside its availability window, a 409 status code if every other one is at its
+// minimum quantity, or a 422 status code if every matching book is out of stock; description
+// describes the matching books in these error messages.
+func checkoutBestMatch(c *gin.Context, description, user string, match func(*book) bool) {
+	lib := currentLibrary(c)
+	now := serverClock.Now()
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	var best, closed, floored *book
+	matched := false
+	for i := range lib.books {
+		b := &lib.books[i]
+		if !match(b) {
+			continue
+		}
+		matched = true
+		if b.availableForCheckout() <= 0 {
+			continue
+		}
+		if !b.inWindow(now) {
+			closed = b
+			continue
+		}
+		if b.atFloor() {
+			floored = b
+			continue
+		}
+		if best == nil || b.availableForCheckout() > best.availableForCheckout() {
+			best = b
+		}
+	}
+
+	if !matched {
+		respondError(c, http.StatusNotFound, "no book "+description)
+		return
+	}
+	if best == nil && floored != nil {
+		respondError(c, http.StatusConflict, "every book "+description+" in stock is at its minimum quantity")
+		return
+	}
+	if best == nil && closed != nil {
+		checkWindow(c, closed, now)
+		return
+	}
+	if best == nil {
+		respondError(c, http.StatusUnprocessableEntity, "every book "+description+" is out of stock")
 		return
 	}
 
-	if book.Quantity <= 0 {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "book is not available at the moment, check in again later"})
+	barcode, err := best.takeCopy("")
+	if err != nil {
+		respondError(c, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
-	book.Quantity -= 1
+	best.touch(now)
+	co := lib.recordCheckout(best, user, barcode)
+	markStoreChanged()
 
-	c.IndentedJSON(http.StatusOK, gin.H{"message": "success", "data": book})
+	respondJSON(c, http.StatusOK, checkoutResponse{Message: "success", Data: best, DueAt: co.DueAt})
 }
 
 // returnBook returns a book by its ID and increments its quantity by 1.
+// For a book that tracks copies, the copy of the cleared checkout becomes available again.
+// If the optional 'user' query parameter is given, that user's checkout of the book is cleared;
+// otherwise the oldest outstanding checkout of the book is cleared.
+// If there is no matching checkout and allowOverstock is set, the quantity is incremented anyway,
+// except for books that track copies, whose quantity cannot exceed their number of copies.
 // If the book is not found, it returns a 404 status code.
-// If the 'id' query parameter is missing, it returns a 400 status code.
+// If the 'id' query parameter is missing, it returns a 400 status code, and if there is no matching
+// checkout to return, a 422 status code.
 func returnBook(c *gin.Context) {
 	id, ok := c.GetQuery("id")
 
 	if !ok {
-		c.IndentedJSON(http.StatusBadRequest, gin.H{"message": "missing query parameter 'id'"})
+		respondError(c, http.StatusBadRequest, "missing query parameter 'id'")
 		return
 	}
 
-	book, err := getBookById(id)
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	book, err := lib.getBookById(id)
 
 	if err != nil {
-		c.IndentedJSON(http.StatusNotFound, gin.H{"message": "book not found"})
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+
+	co, err := lib.clearCheckout(book.ID, c.Query("user"))
+	if err != nil && (!allowOverstock || book.tracksCopies()) {
+		respondError(c, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
-	book.Quantity += 1
-	c.IndentedJSON(http.StatusOK, book)
+	book.returnCopy(co.Barcode)
+	book.touch(serverClock.Now())
+	markStoreChanged()
+	respondJSON(c, http.StatusOK, book)
 }
 
-func main() {
-	// Creates a new default Gin router instance with logging and recovery middleware.
-	router := gin.Default()
+// setupRouter creates the Gin router with its middleware and registers all routes.
+func setupRouter() *gin.Engine {
+	router := gin.New()
+
+	// Trailing slash policy: a request for "/books/" is redirected to "/books" (and vice versa)
+	// when only the other form is registered. GET requests get a 301, every other method a
+	// 307 so that clients repeat the original method and body instead of downgrading to GET.
+	// Paths are never case- or clean-corrected, so "/BOOKS" is a plain 404.
+	router.RedirectTrailingSlash = true
+	router.RedirectFixedPath = false
+
+	// Only trusted proxies may name the client with X-Forwarded-For or X-Real-IP; for everyone else,
+	// the client IP used for rate limiting and logging is the address the request came from.
+	// loadConfig has validated the prefixes, so this cannot fail.
+	_ = router.SetTrustedProxies(prefixStrings(trustedProxies))
+
+	router.Use(otelgin.Middleware(serviceName), traceAttributes())
+	router.Use(trackInFlight(), drainingHeaders(), requestID(), requestLogger(), metricsMiddleware())
+
+	// Recovering from panics keeps a single faulty request from taking the whole server down,
+	// but it also turns bugs into anonymous 500 responses. With recovery disabled, a panic
+	// crashes the process with a full stack trace, which is easier to debug in tests and
+	// local development.
+	if recoverPanics {
+		router.Use(recoverWithAlert())
+	}
+
+	// Injected latency comes after the request logger and metrics, so that it is visible in both,
+	// like real latency would be.
+	if enableChaos && chaosLatency > 0 {
+		router.Use(chaosLatencyMiddleware())
+	}
+
+	router.Use(limitConcurrency(), limitConnsPerIP(), corsMiddleware(), rateLimitMiddleware(), requireScope())
+	if readOnly {
+		router.Use(rejectWrites())
+	}
+	router.Use(requireJSON(), tenantMiddleware())
+
+	router.GET("/metrics", getMetrics)
+
 	router.GET("/books", getBooks)
 	router.POST("/books", createBook)
+	router.POST("/books/import", importBooks)
+	router.POST("/books/validate", validateBook)
+	router.POST("/books/tag", tagBooks)
+	router.DELETE("/books", requireAdmin(), deleteBooks)
+	if featureEnabled("availability") {
+		router.GET("/books/availability", getAvailability)
+	}
+	if featureEnabled("by_author") {
+		router.GET("/books/by-author", getBooksByAuthor)
+	}
+	if featureEnabled("export") {
+		router.GET("/books/export.ndjson", exportNDJSON)
+		router.GET("/books/export", exportChanges)
+	}
+	router.GET("/books/recent", getRecentBooks)
+	router.GET("/books/out-of-stock", getOutOfStockBooks)
+	router.GET("/books/never-borrowed", getNeverBorrowedBooks)
+	router.GET("/books/random", getRandomBook)
+	router.GET("/books/stats", getBookStats)
+	router.GET("/authors", getAuthors)
+	router.GET("/books/find", findBooks)
+	if featureEnabled("inventory") {
+		router.PUT("/books/inventory", syncInventory)
+	}
+	if featureEnabled("search") {
+		router.POST("/books/search", searchBooks)
+	}
 	router.GET("/books/:id", bookById)
+	router.GET("/books/:id/watch", watchBook)
+	router.POST("/books/:id/clone", cloneBook)
+	router.GET("/books/:id/availability-calendar", getAvailabilityCalendar)
+	router.PUT("/books/:id", updateBook)
+	router.PATCH("/books/:id", patchBook)
 	router.PATCH("/checkout", checkoutBook)
+	router.GET("/checkouts/due-today", getCheckoutsDueToday)
+	router.POST("/checkout/by-title", checkoutByTitle)
+	router.POST("/checkout/by-isbn", checkoutByISBN)
 	router.PATCH("/return", returnBook)
-	router.Run("localhost:3001")
+	router.POST("/return/by-isbn", returnByISBN)
+	if featureEnabled("batch_return") {
+		router.POST("/return/batch", returnBooksBatch)
+	}
+
+	admin := router.Group("/admin", requireAdmin())
+	admin.GET("/backup", backupStore)
+	admin.POST("/restore", restoreStore)
+	admin.GET("/config", getConfig)
+	admin.GET("/features", getFeatures)
+	admin.POST("/reindex", reindexBooks)
+	admin.POST("/snapshots/:name", createSnapshot)
+	admin.GET("/snapshots/diff", diffSnapshots)
+
+	return router
+}
 
+// serverHandler returns the handler the HTTP server serves: the router itself, or, if enableH2C
+// is set, the router wrapped so that it also accepts HTTP/2 without TLS. h2c clients either send
+// the HTTP/2 connection preface directly or upgrade from HTTP/1.1; all other requests are
+// served over HTTP/1.1 as before.
+func serverHandler(router http.Handler) http.Handler {
+	if !enableH2C {
+		return router
+	}
+	return h2c.NewHandler(router, &http2.Server{})
+}
+
+func main() {
+	if err := loadConfig(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := verifyStoreIntegrity(strictStartup); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if enableChaos {
+		slog.Warn("chaos mode is enabled; never run it in production", "latency", chaosLatency, "random", chaosLatencyRandom)
+	}
+
+	shutdownTracing, err := setupTracing(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runStatsWorker(ctx)
+	}()
+
+	if autoReturnEnabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			runAutoReturn(ctx, autoReturnInterval, autoReturnAfter)
+		}()
+	}
+
+	router := setupRouter()
+	if printRoutes {
+		if err := printBanner(os.Stdout, router.Routes(), currentConfig()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:    listenAddr,
+		Handler: serverHandler(router),
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	slog.Info("shutting down", "in_flight", inFlightRequests.Load(), "timeout", shutdownTimeout)
+	startDraining()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("in-flight requests did not drain in time, closing remaining connections",
+			"error", err, "in_flight", inFlightRequests.Load())
+		srv.Close()
+	}
+	workers.Wait()
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown failed", "error", err)
+	}
 }
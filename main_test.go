@@ -0,0 +1,352 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// newTestRouter reads the configuration from the environment, as set up by the test with t.Setenv,
+// resets the store to the seed books, and returns a new router.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	if err := loadConfig(); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	resetStore()
+	return setupRouter()
+}
+
+// resetStore discards every tenant's books, checkouts, and snapshots, and everything derived from
+// them, and seeds the default tenant again.
+func resetStore() {
+	storeMu.Lock()
+	libraries = map[string]*library{defaultTenant: newLibrary(seedBooks)}
+	markStoreChanged()
+	storeMu.Unlock()
+
+	snapshotsMu.Lock()
+	snapshots = map[string]map[string]snapshot{}
+	snapshotsMu.Unlock()
+
+	statsCache.Lock()
+	statsCache.byTenant = nil
+	statsCache.Unlock()
+
+	limiter.mu.Lock()
+	limiter.buckets = map[string]*tokenBucket{}
+	limiter.mu.Unlock()
+}
+
+// stopClock stops the server clock at now until the test ends, and returns it so that the test can advance it.
+func stopClock(t *testing.T, now time.Time) *fixedClock {
+	t.Helper()
+
+	clk := &fixedClock{now: now}
+	serverClock = clk
+	t.Cleanup(func() { serverClock = systemClock{} })
+	return clk
+}
+
+// serve sends a request to router and returns the recorded response. A non-empty body is sent as
+// JSON; header lists additional request headers as name, value pairs.
+func serve(router http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode decodes the JSON body of w into a value of type T.
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+// expectStatus fails the test unless w has the given status code.
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body.String())
+	}
+}
+
+func TestGetAvailabilityReportsOutOfStock(t *testing.T) {
+	router := newTestRouter(t)
+
+	for i := 0; i < 2; i++ {
+		expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+	}
+
+	w := serve(router, http.MethodGet, "/books/availability?title=golang", "")
+	expectStatus(t, w, http.StatusOK)
+	got := decode[[]bookAvailability](t, w)
+	want := []bookAvailability{
+		{ID: "1", Available: false, Quantity: 0},
+		{ID: "3", Available: true, Quantity: 30},
+		{ID: "4", Available: true, Quantity: 40},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("availability = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetBooksCoalescesConcurrentRequests(t *testing.T) {
+	router := newTestRouter(t)
+	const n = 20
+
+	// The test leads the shared call for the request's key, so every request that joins it
+	// responds with the test's body instead of reading the store itself.
+	started, release := make(chan struct{}), make(chan struct{})
+	key := fmt.Sprintf("%s?#pretty=%t", defaultTenant, prettyJSON)
+	go listGroup.Do(key, func() (interface{}, error) {
+		close(started)
+		<-release
+		return listCacheEntry{etag: `"coalesced"`, body: []byte(`["coalesced"]`)}, nil
+	})
+	<-started
+
+	responses := make(chan *httptest.ResponseRecorder, n)
+	for i := 0; i < n; i++ {
+		go func() { responses <- serve(router, http.MethodGet, "/books", "") }()
+	}
+	for deadline := time.Now().Add(5 * time.Second); inFlightRequests.Load() < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			close(release)
+			t.Fatalf("%d requests in flight, want %d waiting for the shared call", inFlightRequests.Load(), n)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < n; i++ {
+		w := <-responses
+		expectStatus(t, w, http.StatusOK)
+		if w.Body.String() != `["coalesced"]` {
+			t.Fatalf("body = %s, want the shared response", w.Body.String())
+		}
+	}
+}
+
+func TestTrailingSlashRedirectKeepsMethod(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPost, "/books/", `{"id":"5","title":"Slashed"}`)
+	expectStatus(t, w, http.StatusTemporaryRedirect)
+	if got := w.Header().Get("Location"); got != "/books" {
+		t.Errorf("Location = %q, want /books", got)
+	}
+
+	w = serve(router, http.MethodGet, "/books/", "")
+	expectStatus(t, w, http.StatusMovedPermanently)
+
+	expectStatus(t, serve(router, http.MethodGet, "/BOOKS", ""), http.StatusNotFound)
+}
+
+func TestCreateBookRequiresUniqueID(t *testing.T) {
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"title":"No ID"}`), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"2","title":"Taken"}`), http.StatusConflict)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Free"}`), http.StatusCreated)
+}
+
+func TestRecoverPanics(t *testing.T) {
+	defer func(w io.Writer) { gin.DefaultErrorWriter = w }(gin.DefaultErrorWriter)
+	gin.DefaultErrorWriter = io.Discard
+
+	router := newTestRouter(t)
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+	expectStatus(t, serve(router, http.MethodGet, "/panic", ""), http.StatusInternalServerError)
+
+	t.Setenv("RECOVER_PANICS", "false")
+	router = newTestRouter(t)
+	router.GET("/panic", func(*gin.Context) { panic("boom") })
+	defer func() {
+		if recovered := recover(); recovered != "boom" {
+			t.Errorf("recovered %v, want the handler's panic", recovered)
+		}
+	}()
+	serve(router, http.MethodGet, "/panic", "")
+	t.Error("panic was recovered with RECOVER_PANICS=false")
+}
+
+func TestUniqueISBN(t *testing.T) {
+	for _, unique := range []bool{false, true} {
+		t.Run(fmt.Sprint("unique=", unique), func(t *testing.T) {
+			t.Setenv("UNIQUE_ISBN", strconv.FormatBool(unique))
+			router := newTestRouter(t)
+			expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"isbn":"978-0134190440"}`), http.StatusOK)
+			expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"isbn":"978-1617291784"}`), http.StatusOK)
+
+			updated, created := http.StatusOK, http.StatusCreated
+			if unique {
+				updated, created = http.StatusConflict, http.StatusConflict
+			}
+			expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"isbn":"978-0134190440"}`), updated)
+			expectStatus(t, serve(router, http.MethodPut, "/books/3", `{"id":"3","title":"Golang routers","author":"Mr. Router","quantity":30,"isbn":"978-0134190440"}`), updated)
+			expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Copy","isbn":"978-0134190440"}`), created)
+
+			expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"title":"Same ISBN, new title","isbn":"978-0134190440"}`), http.StatusOK)
+		})
+	}
+}
+
+func TestUniqueTitleAuthor(t *testing.T) {
+	for _, unique := range []bool{false, true} {
+		t.Run(fmt.Sprint("unique=", unique), func(t *testing.T) {
+			t.Setenv("UNIQUE_TITLE_AUTHOR", strconv.FormatBool(unique))
+			router := newTestRouter(t)
+
+			created, updated := http.StatusCreated, http.StatusOK
+			if unique {
+				created, updated = http.StatusConflict, http.StatusConflict
+			}
+			expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Goroutines","author":"Mr. Goroutine"}`), created)
+			expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Goroutines","author":"Ms. Channel"}`), http.StatusCreated)
+			expectStatus(t, serve(router, http.MethodPatch, "/books/6", `{"author":"Mr. Goroutine"}`), updated)
+			expectStatus(t, serve(router, http.MethodPut, "/books/3", `{"title":"Goroutines","author":"Mr. Goroutine","quantity":30}`), updated)
+
+			expectStatus(t, serve(router, http.MethodPut, "/books/2", `{"title":"Goroutines","author":"Mr. Goroutine","quantity":5}`), http.StatusOK)
+		})
+	}
+}
+
+func TestCheckoutResponseBytes(t *testing.T) {
+	stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPatch, "/checkout?id=2&user=ann", "")
+	expectStatus(t, w, http.StatusOK)
+	want := `{
+    "message": "success",
+    "data": {
+        "id": "2",
+        "title": "Goroutines",
+        "author": "Mr. Goroutine",
+        "isbn": "",
+        "quantity": 19,
+        "available_for_checkout": 19,
+        "borrow_count": 1,
+        "created_at": "2024-03-01T12:00:00Z",
+        "updated_at": "2024-03-01T12:00:00Z"
+    },
+    "due_at": "2024-03-15T12:00:00Z"
+}`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCreateBookDefaultQuantity(t *testing.T) {
+	t.Setenv("DEFAULT_QUANTITY", "3")
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPost, "/books", `{"id":"5","title":"Omitted"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.Quantity != 3 {
+		t.Errorf("quantity = %d, want the default 3", b.Quantity)
+	}
+
+	w = serve(router, http.MethodPost, "/books", `{"id":"6","title":"Explicit","quantity":0}`)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.Quantity != 0 {
+		t.Errorf("quantity = %d, want the explicit 0", b.Quantity)
+	}
+}
+
+func TestCreateBookDefaultAuthor(t *testing.T) {
+	t.Setenv("DEFAULT_AUTHOR", "Unknown")
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPost, "/books", `{"id":"5","title":"Anonymous"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.Author != "Unknown" {
+		t.Errorf("author = %q, want the default", b.Author)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Blank","author":""}`), http.StatusBadRequest)
+}
+
+func TestBookCoverURL(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPost, "/books", `{"id":"5","title":"Covered","cover_url":"https://example.com/covers/5.jpg"}`)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.CoverURL != "https://example.com/covers/5.jpg" {
+		t.Errorf("cover_url = %q, want the given URL", b.CoverURL)
+	}
+
+	for _, invalid := range []string{"ftp://example.com/5.jpg", "/covers/5.jpg", "https://"} {
+		expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Bad","cover_url":"`+invalid+`"}`), http.StatusBadRequest)
+		expectStatus(t, serve(router, http.MethodPatch, "/books/5", `{"cover_url":"`+invalid+`"}`), http.StatusBadRequest)
+	}
+
+	w = serve(router, http.MethodPatch, "/books/5", `{"cover_url":""}`)
+	expectStatus(t, w, http.StatusOK)
+	if strings.Contains(w.Body.String(), "cover_url") {
+		t.Errorf("body = %s, want no cover_url once it is cleared", w.Body.String())
+	}
+}
+
+func TestGetBooksEmptyAs204(t *testing.T) {
+	for _, as204 := range []bool{false, true} {
+		t.Run(fmt.Sprint("EMPTY_AS_204=", as204), func(t *testing.T) {
+			t.Setenv("EMPTY_AS_204", strconv.FormatBool(as204))
+			router := newTestRouter(t)
+
+			// The second request is served from the cache.
+			for i := 0; i < 2; i++ {
+				w := serve(router, http.MethodGet, "/books?title=rust", "")
+				if as204 {
+					expectStatus(t, w, http.StatusNoContent)
+					if w.Body.Len() != 0 {
+						t.Errorf("body = %q, want none", w.Body.String())
+					}
+				} else {
+					expectStatus(t, w, http.StatusOK)
+					if books := decode[[]book](t, w); books == nil || len(books) != 0 {
+						t.Errorf("books = %v, want an empty list", books)
+					}
+				}
+			}
+
+			expectStatus(t, serve(router, http.MethodGet, "/books?title=golang", ""), http.StatusOK)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// mergePatchContentType is the media type of JSON Merge Patch documents (RFC 7386).
+const mergePatchContentType = "application/merge-patch+json"
+
+// isMergePatch reports whether the request body is a JSON Merge Patch document.
+func isMergePatch(c *gin.Context) bool {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	return err == nil && mediaType == mergePatchContentType
+}
+
+// bindMergePatch decodes a JSON Merge Patch document from the request body into patch and validates it.
+// As with any patch, members that are left out leave the corresponding field untouched, but a
+// member set to null clears the field: it is set to its zero value, e.g. an empty category or
+// a loan_days of 0. Members that do not name a field of patch are ignored, or rejected if strict
+// JSON decoding is configured.
+func bindMergePatch(c *gin.Context, patch *bookPatch) error {
+	if c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+
+	if body = bytes.TrimSpace(body); len(body) == 0 || body[0] != '{' {
+		return errors.New("merge patch must be a JSON object")
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(patch).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		raw, ok := members[name]
+		if !ok {
+			continue
+		}
+		delete(members, name)
+
+		field := v.Field(i)
+		field.Set(reflect.New(field.Type().Elem()))
+		if string(raw) == "null" {
+			continue
+		}
+		if err := json.Unmarshal(raw, field.Interface()); err != nil {
+			return err
+		}
+	}
+
+	if strictJSON {
+		for name := range members {
+			return errors.New("json: unknown field \"" + name + "\"")
+		}
+	}
+	return binding.Validator.ValidateStruct(patch)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestUpdateBookMergePatch(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"category":"programming","loan_days":7}`), http.StatusOK)
+
+	// A plain JSON null leaves the field untouched.
+	w := serve(router, http.MethodPatch, "/books/1", `{"category":null}`)
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.Category != "programming" {
+		t.Errorf("category = %q after a plain patch with null, want it untouched", b.Category)
+	}
+
+	w = serve(router, http.MethodPatch, "/books/1", `{"title":"Golang pointers, 2nd edition","category":null}`, "Content-Type", "application/merge-patch+json")
+	expectStatus(t, w, http.StatusOK)
+	b := decode[book](t, w)
+	if b.Title != "Golang pointers, 2nd edition" || b.Category != "" {
+		t.Errorf("title %q and category %q after the merge patch, want the new title and no category", b.Title, b.Category)
+	}
+	if b.Author != "Mr. Golang" || b.Quantity != 2 || b.LoanDays != 7 {
+		t.Errorf("book = %+v, want the fields left out of the merge patch untouched", b)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `["title"]`, "Content-Type", "application/merge-patch+json"), http.StatusBadRequest)
+}
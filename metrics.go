@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute is the route label used for requests that did not match any registered route.
+const unmatchedRoute = "unmatched"
+
+// requestSeries identifies a single metric series of handled requests.
+// The route is the route template (e.g. "/books/:id") rather than the concrete path,
+// which keeps the number of series bounded no matter how many book IDs are requested.
+type requestSeries struct {
+	Method string
+	Route  string
+	Status int
+}
+
+// requestStats accumulates the requests counted for a single series.
+type requestStats struct {
+	Count       uint64
+	DurationSum float64
+}
+
+var (
+	metricsMu      sync.Mutex
+	requestMetrics = map[requestSeries]*requestStats{}
+)
+
+// metricsMiddleware returns a middleware that counts every request and its duration,
+// labelled by method, route template, and response status.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		series := requestSeries{Method: c.Request.Method, Route: route, Status: c.Writer.Status()}
+
+		metricsMu.Lock()
+		defer metricsMu.Unlock()
+
+		stats, ok := requestMetrics[series]
+		if !ok {
+			stats = &requestStats{}
+			requestMetrics[series] = stats
+		}
+		stats.Count++
+		stats.DurationSum += time.Since(start).Seconds()
+	}
+}
+
+// getMetrics exposes the collected request metrics in the Prometheus text exposition format.
+func getMetrics(c *gin.Context) {
+	metricsMu.Lock()
+	series := make([]requestSeries, 0, len(requestMetrics))
+	stats := make(map[requestSeries]requestStats, len(requestMetrics))
+	for s, st := range requestMetrics {
+		series = append(series, s)
+		stats[s] = *st
+	}
+	metricsMu.Unlock()
+
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i], series[j]
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Status < b.Status
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# HELP http_requests_total Total number of handled HTTP requests.\n")
+	sb.WriteString("# TYPE http_requests_total counter\n")
+	for _, s := range series {
+		fmt.Fprintf(&sb, "http_requests_total{%s} %d\n", s.labels(), stats[s].Count)
+	}
+
+	sb.WriteString("# HELP http_request_duration_seconds Time spent handling HTTP requests.\n")
+	sb.WriteString("# TYPE http_request_duration_seconds summary\n")
+	for _, s := range series {
+		fmt.Fprintf(&sb, "http_request_duration_seconds_sum{%s} %g\n", s.labels(), stats[s].DurationSum)
+		fmt.Fprintf(&sb, "http_request_duration_seconds_count{%s} %d\n", s.labels(), stats[s].Count)
+	}
+
+	sb.WriteString("# HELP http_requests_in_flight Number of HTTP requests currently being handled.\n")
+	sb.WriteString("# TYPE http_requests_in_flight gauge\n")
+	fmt.Fprintf(&sb, "http_requests_in_flight %d\n", inFlightRequests.Load())
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(sb.String()))
+}
+
+// labels formats the series as a Prometheus label set.
+func (s requestSeries) labels() string {
+	return fmt.Sprintf("method=%s,route=%s,status=%s",
+		strconv.Quote(s.Method), strconv.Quote(s.Route), strconv.Quote(strconv.Itoa(s.Status)))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsLabelRouteTemplates(t *testing.T) {
+	router := newTestRouter(t)
+	metricsMu.Lock()
+	requestMetrics = map[requestSeries]*requestStats{}
+	metricsMu.Unlock()
+
+	for _, path := range []string{"/books/1", "/books/2", "/books/3", "/books/99", "/no/such/route"} {
+		serve(router, http.MethodGet, path, "")
+	}
+
+	w := serve(router, http.MethodGet, "/metrics", "")
+	expectStatus(t, w, http.StatusOK)
+	body := w.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/books/:id",status="200"} 3`,
+		`http_requests_total{method="GET",route="/books/:id",status="404"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `route="/books/1"`) {
+		t.Errorf("metrics are labelled by concrete path:\n%s", body)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	mathrand "math/rand"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDKey is the gin.Context key under which requestID stores the request's ID.
+const requestIDKey = "request_id"
+
+// requestID returns a middleware that assigns every request an ID, taken from the X-Request-ID
+// request header if the client sent one and generated otherwise. The ID is echoed in the
+// X-Request-ID response header so that clients can quote it when reporting problems.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// newRequestID returns a random 128-bit request ID in hexadecimal.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// inFlightRequests is the number of requests currently being handled.
+var inFlightRequests atomic.Int64
+
+// trackInFlight returns a middleware that counts the requests being handled in inFlightRequests,
+// so that a graceful shutdown can report how many requests it is waiting for.
+func trackInFlight() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inFlightRequests.Add(1)
+		defer inFlightRequests.Add(-1)
+		c.Next()
+	}
+}
+
+// concurrencyExemptPaths lists the routes that limitConcurrency never rejects: the metrics
+// endpoint, so that an overloaded server can still be observed, and long-polls, which spend their
+// time waiting rather than working.
+var concurrencyExemptPaths = []string{"/metrics", "/books/:id/watch"}
+
+// limitConcurrency returns a middleware that handles at most maxConcurrent requests at a time.
+// Requests over the limit are rejected at once with status code 503 (Service Unavailable) and
+// a Retry-After header instead of queueing up. A limit of 0 disables the middleware.
+func limitConcurrency() gin.HandlerFunc {
+	if maxConcurrent == 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	slots := make(chan struct{}, maxConcurrent)
+	return func(c *gin.Context) {
+		if slices.Contains(concurrencyExemptPaths, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			setRetryAfter(c, time.Second)
+			respondError(c, http.StatusServiceUnavailable, "server is at its limit of "+strconv.Itoa(maxConcurrent)+" concurrent requests")
+			c.Abort()
+			return
+		}
+		defer func() { <-slots }()
+
+		c.Next()
+	}
+}
+
+// draining is set when a graceful shutdown starts, after which responses announce that the server
+// is going away; see drainingHeaders.
+var draining atomic.Bool
+
+// shutdownStarted is closed when a graceful shutdown starts, so that handlers that wait, such as
+// watchBook, can return right away instead of holding up the drain.
+var shutdownStarted = make(chan struct{})
+
+// startDraining starts the graceful shutdown: it sets draining and closes shutdownStarted.
+func startDraining() {
+	if draining.CompareAndSwap(false, true) {
+		close(shutdownStarted)
+	}
+}
+
+// drainingHeaders returns a middleware that adds "Connection: close" and "X-Server-Draining: true"
+// headers to every response written while the server is draining, so that clients stop reusing
+// the connection and can switch to another instance. Requests that were already in flight when
+// the drain started get the headers too, as long as they have not started writing their response.
+func drainingHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &drainingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// Responses without a body, such as 304s, are only written by gin after the handlers return.
+		w.addHeaders()
+	}
+}
+
+// drainingWriter adds the draining headers right before the response header is written.
+type drainingWriter struct {
+	gin.ResponseWriter
+}
+
+// addHeaders adds the draining headers if the server is draining and the header has not been written yet.
+func (w *drainingWriter) addHeaders() {
+	if draining.Load() && !w.Written() {
+		w.Header().Set("Connection", "close")
+		w.Header().Set("X-Server-Draining", "true")
+	}
+}
+
+func (w *drainingWriter) WriteHeaderNow() {
+	w.addHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *drainingWriter) Write(data []byte) (int, error) {
+	w.addHeaders()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *drainingWriter) WriteString(s string) (int, error) {
+	w.addHeaders()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestLogger returns a middleware that logs requests after they have been handled.
+// Requests taking longer than slowRequestThreshold are logged at warning level so that
+// performance regressions stand out; all other requests are logged at info level.
+// Only a logSampleRate fraction of fast successful requests is logged, to keep the log volume
+// down under high traffic; slow requests and failed requests are always logged.
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start)
+		attrs := []any{
+			"request_id", c.GetString(requestIDKey),
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", duration,
+		}
+
+		if slowRequestThreshold > 0 && duration > slowRequestThreshold {
+			slog.Warn("slow request", attrs...)
+			return
+		}
+
+		if status := c.Writer.Status(); status >= 200 && status < 300 && !sampled(logSampleRate) {
+			return
+		}
+		slog.Info("request", attrs...)
+	}
+}
+
+// sampled reports whether an event sampled at the given rate, between 0 and 1, should be kept.
+func sampled(rate float64) bool {
+	return rate >= 1 || (rate > 0 && mathrand.Float64() < rate)
+}
+
+// corsAllowedHeaders lists the request headers the API reads, which cross-origin requests may send.
+var corsAllowedHeaders = []string{
+	"Content-Type", "If-Modified-Since", "If-None-Match", "If-Unmodified-Since", "Range",
+	"traceparent", "tracestate", "X-API-Key", "X-Pretty-Print", "X-Request-ID", "X-Tenant-ID",
+}
+
+// corsExposedHeaders lists the response headers beyond the CORS-safelisted ones that scripts of
+// allowed origins may read.
+var corsExposedHeaders = []string{
+	"Accept-Ranges", "Content-Disposition", "Content-Range", "ETag", "Last-Modified", "Retry-After",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Request-ID", "X-Server-Draining",
+}
+
+// corsMiddleware returns a middleware that adds CORS headers for requests from allowed origins.
+// Preflight requests are answered directly with status code 204 (No Content), the methods and
+// corsAllowedHeaders, and an Access-Control-Max-Age header so browsers can cache the result for
+// corsMaxAge seconds. Other responses expose corsExposedHeaders.
+func corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !originAllowed(origin) {
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Vary", "Origin")
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+			c.Header("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Header("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		c.Next()
+	}
+}
+
+// originAllowed reports whether origin is listed in corsAllowedOrigins.
+func originAllowed(origin string) bool {
+	for _, allowed := range corsAllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin returns a middleware that only lets requests through whose X-API-Key header
+// matches adminAPIKey or a scoped API key with the admin scope. If no such key is configured,
+// every request is rejected. A known key without the admin scope is rejected with status code
+// 403 (Forbidden), any other request with 401 (Unauthorized).
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if hasScope(key, scopeAdmin) {
+			c.Next()
+			return
+		}
+
+		if knownAPIKey(key) {
+			respondError(c, http.StatusForbidden, "API key lacks the 'admin' scope")
+		} else {
+			respondError(c, http.StatusUnauthorized, "admin API key required")
+		}
+		c.Abort()
+	}
+}
+
+// tenantMiddleware returns a middleware that determines the tenant a request is made for from
+// its X-Tenant-ID header, falling back to the default tenant when the header is absent.
+// If a tenant allowlist is configured, requests for any other tenant are rejected with 403.
+func tenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant := strings.TrimSpace(c.GetHeader("X-Tenant-ID"))
+		if tenant == "" {
+			tenant = defaultTenant
+		}
+
+		if tenant != defaultTenant && len(tenantAllowlist) > 0 && !slices.Contains(tenantAllowlist, tenant) {
+			respondError(c, http.StatusForbidden, "unknown tenant '"+tenant+"'")
+			c.Abort()
+			return
+		}
+
+		c.Set(tenantKey, tenant)
+		c.Next()
+	}
+}
+
+// rejectWrites returns a middleware for read-only deployments that rejects every POST, PUT,
+// PATCH, and DELETE request with status code 405 (Method Not Allowed). Reads pass.
+func rejectWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			c.Header("Allow", "GET, OPTIONS")
+			respondError(c, http.StatusMethodNotAllowed, "the server is read-only; "+c.Request.Method+" requests are disabled")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// uploadRoutes lists the routes that accept multipart/form-data file uploads.
+var uploadRoutes = []string{"/books/import"}
+
+// requireJSON returns a middleware that rejects POST, PUT, and PATCH requests with a body whose
+// Content-Type is not application/json (optionally with parameters such as a charset) with
+// status code 415 (Unsupported Media Type). PATCH requests may also send application/merge-patch+json,
+// and the routes listed in uploadRoutes multipart/form-data. Requests without a body, such as checkouts, pass.
+func requireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		mergePatch := c.Request.Method == http.MethodPatch && mediaType == mergePatchContentType
+		upload := mediaType == "multipart/form-data" && slices.Contains(uploadRoutes, c.FullPath())
+		if err != nil || (mediaType != "application/json" && !mergePatch && !upload) {
+			respondError(c, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
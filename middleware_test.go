@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// captureLogs makes slog write to the returned buffer until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestRequestLoggerWarnsAboutSlowRequests(t *testing.T) {
+	t.Setenv("SLOW_REQUEST_MS", "20")
+	newTestRouter(t)
+	logs := captureLogs(t)
+
+	router := gin.New()
+	router.Use(requestLogger())
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve(router, http.MethodGet, "/slow", "")
+	out := logs.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="slow request"`) {
+		t.Fatalf("slow request not logged as a warning: %s", out)
+	}
+	for _, attr := range []string{"method=GET", "path=/slow", "duration="} {
+		if !strings.Contains(out, attr) {
+			t.Errorf("slow request log lacks %s: %s", attr, out)
+		}
+	}
+
+	logs.Reset()
+	serve(router, http.MethodGet, "/fast", "")
+	if out := logs.String(); !strings.Contains(out, "level=INFO") || strings.Contains(out, "slow request") {
+		t.Errorf("fast request not logged at info level: %s", out)
+	}
+}
+
+func TestCORSPreflightMaxAge(t *testing.T) {
+	t.Setenv("CORS_MAX_AGE", "120")
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodOptions, "/books", "",
+		"Origin", "https://app.example.com",
+		"Access-Control-Request-Method", "POST")
+	expectStatus(t, w, http.StatusNoContent)
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "120" {
+		t.Errorf("Access-Control-Max-Age = %q, want 120", got)
+	}
+
+	t.Setenv("CORS_MAX_AGE", "-1")
+	if err := loadConfig(); err == nil {
+		t.Error("loadConfig accepted a negative CORS_MAX_AGE")
+	}
+}
+
+func TestCORSPreflightAllowsAPIHeaders(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodOptions, "/books", "",
+		"Origin", "https://app.example.com",
+		"Access-Control-Request-Method", "POST",
+		"Access-Control-Request-Headers", "x-tenant-id, x-api-key, if-unmodified-since")
+	expectStatus(t, w, http.StatusNoContent)
+
+	allowed := strings.ToLower(w.Header().Get("Access-Control-Allow-Headers"))
+	for _, name := range []string{"x-tenant-id", "x-api-key", "if-unmodified-since", "x-request-id", "content-type"} {
+		if !strings.Contains(allowed, name) {
+			t.Errorf("Access-Control-Allow-Headers = %q, lacks %s", allowed, name)
+		}
+	}
+}
+
+func TestCORSExposesResponseHeaders(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/books", "", "Origin", "https://app.example.com")
+	expectStatus(t, w, http.StatusOK)
+	exposed := w.Header().Get("Access-Control-Expose-Headers")
+	for _, name := range []string{"ETag", "X-Request-ID", "Retry-After", "X-RateLimit-Remaining"} {
+		if !strings.Contains(exposed, name) {
+			t.Errorf("Access-Control-Expose-Headers = %q, lacks %s", exposed, name)
+		}
+	}
+}
+
+func TestRequireJSONContentType(t *testing.T) {
+	router := newTestRouter(t)
+	body := `{"id":"5","title":"Typed"}`
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", body, "Content-Type", "text/plain"), http.StatusUnsupportedMediaType)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"quantity":3}`, "Content-Type", "application/x-www-form-urlencoded"), http.StatusUnsupportedMediaType)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/1", `{"quantity":3}`, "Content-Type", "application/merge-patch+json"), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPost, "/books", body, "Content-Type", "application/json; charset=utf-8"), http.StatusCreated)
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	router := newTestRouter(t)
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		resp, err := http.Get(srv.URL + "/slow")
+		done <- result{resp, err}
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); inFlightRequests.Load() != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("slow request never started")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown did not drain: %v", err)
+	}
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("slow request failed during shutdown: %v", r.err)
+	}
+	defer r.resp.Body.Close()
+	if body, _ := io.ReadAll(r.resp.Body); r.resp.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Errorf("slow request got %d %q, want it to complete", r.resp.StatusCode, body)
+	}
+	if n := inFlightRequests.Load(); n != 0 {
+		t.Errorf("%d requests in flight after the drain, want 0", n)
+	}
+}
+
+func TestLimitConcurrencyRejectsOverflow(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT", "2")
+	router := newTestRouter(t)
+	entered, release := make(chan struct{}), make(chan struct{})
+	router.GET("/block", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	for i := 0; i < 2; i++ {
+		go func() { done <- serve(router, http.MethodGet, "/block", "") }()
+		<-entered
+	}
+
+	w := serve(router, http.MethodGet, "/books", "")
+	expectStatus(t, w, http.StatusServiceUnavailable)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("overflow response has no Retry-After header")
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/metrics", ""), http.StatusOK)
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		expectStatus(t, <-done, http.StatusNoContent)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books", ""), http.StatusOK)
+}
+
+func TestRequestLoggerSamplesSuccessfulRequests(t *testing.T) {
+	t.Setenv("LOG_SAMPLE_RATE", "0")
+	t.Setenv("SLOW_REQUEST_MS", "20")
+	newTestRouter(t)
+	logs := captureLogs(t)
+
+	router := gin.New()
+	router.Use(requestLogger())
+	router.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/fail", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+
+	serve(router, http.MethodGet, "/ok", "")
+	if out := logs.String(); out != "" {
+		t.Errorf("successful request logged with LOG_SAMPLE_RATE=0: %s", out)
+	}
+	for _, path := range []string{"/fail", "/slow"} {
+		logs.Reset()
+		serve(router, http.MethodGet, path, "")
+		if out := logs.String(); !strings.Contains(out, "path="+path) {
+			t.Errorf("request to %s not logged with LOG_SAMPLE_RATE=0: %q", path, out)
+		}
+	}
+}
+
+func TestDrainingHeaders(t *testing.T) {
+	router := newTestRouter(t)
+	t.Cleanup(func() {
+		draining.Store(false)
+		shutdownStarted = make(chan struct{})
+	})
+	entered, release := make(chan struct{}), make(chan struct{})
+	router.GET("/block", func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+
+	w := serve(router, http.MethodGet, "/books/1", "")
+	if got := w.Header().Get("X-Server-Draining"); got != "" {
+		t.Errorf("X-Server-Draining = %q before the shutdown, want none", got)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(router, http.MethodGet, "/block", "") }()
+	<-entered
+	startDraining()
+	close(release)
+
+	for _, w := range []*httptest.ResponseRecorder{<-done, serve(router, http.MethodGet, "/books/1", "")} {
+		if w.Header().Get("X-Server-Draining") != "true" || w.Header().Get("Connection") != "close" {
+			t.Errorf("headers = %v during the drain, want X-Server-Draining and Connection: close", w.Header())
+		}
+	}
+}
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	t.Setenv("READ_ONLY", "true")
+	router := newTestRouter(t)
+
+	for _, w := range []*httptest.ResponseRecorder{
+		serve(router, http.MethodPost, "/books", `{"id":"5","title":"New"}`),
+		serve(router, http.MethodPut, "/books/1", `{"id":"1","title":"Replaced"}`),
+		serve(router, http.MethodPatch, "/checkout?id=2", ""),
+		serve(router, http.MethodDelete, "/books", `{"ids":["1"]}`),
+	} {
+		expectStatus(t, w, http.StatusMethodNotAllowed)
+		if got := w.Header().Get("Allow"); got != "GET, OPTIONS" {
+			t.Errorf("Allow = %q, want GET, OPTIONS", got)
+		}
+	}
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/1", ""), http.StatusOK)
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/2", "")); b.Quantity != 20 {
+		t.Errorf("quantity = %d, want the rejected checkout to leave 20", b.Quantity)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPerPage is the page size used by paginated endpoints when 'per_page' is not given.
+const defaultPerPage = 20
+
+// maxPerPage is the largest page size accepted by paginated endpoints.
+const maxPerPage = 100
+
+// parsePagination reads the 'page' and 'per_page' query parameters, defaulting to the first
+// page of defaultPerPage items. It returns an error if either is not a positive integer or
+// per_page exceeds maxPerPage.
+func parsePagination(c *gin.Context) (page, perPage int, err error) {
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, errInvalidQuery("page", "a positive integer")
+	}
+
+	perPage, err = strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		return 0, 0, errInvalidQuery("per_page", "an integer between 1 and "+strconv.Itoa(maxPerPage))
+	}
+
+	return page, perPage, nil
+}
+
+// paginate returns the items of the given 1-based page, or an empty slice if the page is out of range.
+// The page is checked against the number of pages before its offset is computed, so that a huge
+// page cannot overflow the offset.
+func paginate[T any](items []T, page, perPage int) []T {
+	if page-1 >= (len(items)+perPage-1)/perPage {
+		return []T{}
+	}
+	start := (page - 1) * perPage
+	end := min(start+perPage, len(items))
+	return items[start:end]
+}
+
+// checkPageInRange reports whether the requested page may be served for a list of total items.
+// By default every page may be served, with pages past the end being empty. With the query
+// parameter 'strict_paging=true', a page past the last one is rejected with status code 404
+// (Not Found) and a message naming the last valid page; an empty list has a single, empty page.
+// It responds with status code 400 if 'strict_paging' is not a boolean.
+func checkPageInRange(c *gin.Context, page, perPage, total int) bool {
+	strict, err := strconv.ParseBool(c.DefaultQuery("strict_paging", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("strict_paging", "a boolean").Error())
+		return false
+	}
+
+	lastPage := max(1, (total+perPage-1)/perPage)
+	if strict && page > lastPage {
+		respondError(c, http.StatusNotFound, fmt.Sprintf("page %d is out of range, the last page is %d", page, lastPage))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPageOutOfRange(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/authors?per_page=2&page=3", "")
+	expectStatus(t, w, http.StatusOK)
+	if page := decode[authorSummariesPage](t, w); len(page.Authors) != 0 || page.TotalAuthors != 4 {
+		t.Errorf("lenient page = %+v, want an empty page of 4 authors", page)
+	}
+
+	w = serve(router, http.MethodGet, "/authors?per_page=2&page=3&strict_paging=true", "")
+	expectStatus(t, w, http.StatusNotFound)
+	if msg := decode[errorResponse](t, w).Message; !strings.Contains(msg, "last page is 2") {
+		t.Errorf("message = %q, want it to name the last page", msg)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/authors?per_page=2&page=2&strict_paging=true", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodGet, "/authors?search=nobody&strict_paging=true", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodGet, "/books/by-author?page=2&strict_paging=true", ""), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodGet, "/authors?strict_paging=maybe", ""), http.StatusBadRequest)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quantity is a number of copies of a book.
+// When decoded from JSON it tolerates the representations clients commonly send:
+// an integer (5), a whole floating point number (5.0), or a numeric string ("5").
+// Fractional values such as 5.5 are rejected.
+type quantity int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (q *quantity) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	raw := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+	}
+
+	if n, err := strconv.ParseInt(raw, 10, 32); err == nil {
+		*q = quantity(n)
+		return nil
+	} else if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("invalid quantity %s: out of range", data)
+	}
+
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("invalid quantity %s: must be a whole number", data)
+	}
+	if f != math.Trunc(f) {
+		return fmt.Errorf("invalid quantity %s: must be a whole number, not a fraction", data)
+	}
+	if f > math.MaxInt32 || f < math.MinInt32 {
+		return fmt.Errorf("invalid quantity %s: out of range", data)
+	}
+
+	*q = quantity(f)
+	return nil
+}
+
+// maxQuantityError returns an error naming the limit if q exceeds the configured maximum
+// quantity of a single book, and nil otherwise.
+func maxQuantityError(q quantity) error {
+	if maxBookQuantity > 0 && q > maxBookQuantity {
+		return fmt.Errorf("quantity %d exceeds the maximum of %d per book", q, maxBookQuantity)
+	}
+	return nil
+}
+
+// checkMaxQuantity reports whether q is within the configured maximum quantity of a single book.
+// If it is not, it responds with status code 400 (Bad Request) naming the limit and returns false.
+func checkMaxQuantity(c *gin.Context, q quantity) bool {
+	if err := maxQuantityError(q); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
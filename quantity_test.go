@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestQuantityUnmarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want quantity
+	}{
+		{`5`, 5},
+		{`5.0`, 5},
+		{`"5"`, 5},
+		{`"-3"`, -3},
+		{`2147483647`, 2147483647},
+		{`-2147483648`, -2147483648},
+	} {
+		var q quantity
+		if err := json.Unmarshal([]byte(tc.in), &q); err != nil {
+			t.Errorf("%s: %v", tc.in, err)
+		} else if q != tc.want {
+			t.Errorf("%s: got %d, want %d", tc.in, q, tc.want)
+		}
+	}
+}
+
+func TestQuantityUnmarshalJSONRejectsInvalid(t *testing.T) {
+	for _, in := range []string{
+		`5.5`,
+		`"five"`,
+		`2147483648`,
+		`-2147483649`,
+		`"3000000000"`,
+		`9223372036854775808`,
+		`3e9`,
+	} {
+		var q quantity
+		if err := json.Unmarshal([]byte(in), &q); err == nil {
+			t.Errorf("%s: got %d, want an error", in, q)
+		}
+	}
+}
+
+func TestMaxBookQuantity(t *testing.T) {
+	t.Setenv("MAX_BOOK_QUANTITY", "100")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Typo","quantity":10000}`), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Fine","quantity":100}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPatch, "/books/5", `{"quantity":101}`), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodPut, "/books/inventory", `[{"id":"5","quantity":101}]`), http.StatusBadRequest)
+
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); b.Quantity != 100 {
+		t.Errorf("quantity = %d, want 100", b.Quantity)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// randomMu guards randomSource, which is not safe for concurrent use.
+	randomMu sync.Mutex
+	// randomSource picks the books returned by getRandomBook. It is seeded by seedRandom.
+	randomSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// seedRandom reseeds the source used by getRandomBook. A fixed seed makes the selection
+// deterministic for a given store, which is useful in tests.
+func seedRandom(seed int64) {
+	randomMu.Lock()
+	defer randomMu.Unlock()
+	randomSource = rand.New(rand.NewSource(seed))
+}
+
+// getRandomBook returns a single randomly selected book of the tenant that is in stock, for discovery.
+// The optional 'category' query parameter restricts the selection to books of that category,
+// matched case-insensitively. It returns a 404 status code if no in-stock book matches.
+func getRandomBook(c *gin.Context) {
+	category := c.Query("category")
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	var candidates []*book
+	for i := range lib.books {
+		b := &lib.books[i]
+		if b.Quantity <= 0 || (category != "" && !strings.EqualFold(b.Category, category)) {
+			continue
+		}
+		candidates = append(candidates, b)
+	}
+
+	if len(candidates) == 0 {
+		respondError(c, http.StatusNotFound, "no book in stock matches")
+		return
+	}
+
+	randomMu.Lock()
+	pick := candidates[randomSource.Intn(len(candidates))]
+	randomMu.Unlock()
+
+	respondJSON(c, http.StatusOK, pick)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRandomBookIsDeterministicWithFixedSeed(t *testing.T) {
+	router := newTestRouter(t)
+
+	pick := func() string {
+		w := serve(router, http.MethodGet, "/books/random", "")
+		expectStatus(t, w, http.StatusOK)
+		return decode[book](t, w).ID
+	}
+
+	seedRandom(42)
+	first := []string{pick(), pick(), pick(), pick(), pick()}
+	seedRandom(42)
+	for i, want := range first {
+		if got := pick(); got != want {
+			t.Fatalf("pick %d = %s after reseeding, want %s", i, got, want)
+		}
+	}
+}
+
+func TestRandomBookFiltersByCategory(t *testing.T) {
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/random?category=poetry", ""), http.StatusNotFound)
+
+	expectStatus(t, serve(router, http.MethodPatch, "/books/3", `{"category":"Poetry"}`), http.StatusOK)
+	w := serve(router, http.MethodGet, "/books/random?category=poetry", "")
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.ID != "3" {
+		t.Errorf("picked book %s, want the only poetry book 3", b.ID)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route groups that can be given their own rate limit.
+const (
+	rateGroupReads  = "reads"
+	rateGroupWrites = "writes"
+)
+
+// maxRateBuckets is the number of client buckets above which idle, full buckets are discarded.
+const maxRateBuckets = 10000
+
+// rateLimit allows Burst requests per Per, refilled continuously.
+type rateLimit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// String formats the limit the way it is configured, e.g. "10/s".
+func (l rateLimit) String() string {
+	unit := map[time.Duration]string{time.Second: "s", time.Minute: "m", time.Hour: "h"}[l.Per]
+	return strconv.Itoa(l.Burst) + "/" + unit
+}
+
+// parseRateLimits parses a comma separated list of per route group limits, e.g. "reads=100/s,writes=10/s".
+// The groups are rateGroupReads and rateGroupWrites; the unit is one of s, m, or h.
+func parseRateLimits(v string) (map[string]rateLimit, error) {
+	limits := map[string]rateLimit{}
+	if v == "" {
+		return limits, nil
+	}
+
+	units := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	for _, entry := range strings.Split(v, ",") {
+		group, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || (group != rateGroupReads && group != rateGroupWrites) {
+			return nil, fmt.Errorf("'%s' must be reads=N/unit or writes=N/unit", entry)
+		}
+
+		count, unit, ok := strings.Cut(spec, "/")
+		n, err := strconv.Atoi(count)
+		if !ok || err != nil || n < 1 || units[unit] == 0 {
+			return nil, fmt.Errorf("'%s' must be a positive number of requests per s, m, or h", spec)
+		}
+		limits[group] = rateLimit{Burst: n, Per: units[unit]}
+	}
+	return limits, nil
+}
+
+// rateGroup returns the route group the request counts against: writes for requests that modify
+// the store, reads for everything else.
+func rateGroup(c *gin.Context) string {
+	switch c.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return rateGroupWrites
+	default:
+		return rateGroupReads
+	}
+}
+
+// tokenBucket is the remaining allowance of a single client for a single route group.
+type tokenBucket struct {
+	limit   rateLimit
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter holds the token buckets of all clients, keyed by route group and client IP.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// limiter is the rate limiter used by rateLimitMiddleware.
+var limiter = rateLimiter{buckets: map[string]*tokenBucket{}}
+
+// rateDecision is the outcome of rateLimiter.allow.
+type rateDecision struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Remaining is the number of whole requests left in the bucket after this one.
+	Remaining int
+	// RetryAfter is how long until the next token is available, if the request is not allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// allow takes a token from the bucket of key, which is limited by limit, at now.
+// If the bucket is empty, the request is not allowed and no token is taken.
+func (rl *rateLimiter) allow(key string, limit rateLimit, now time.Time) rateDecision {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	perToken := limit.Per / time.Duration(limit.Burst)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		if len(rl.buckets) >= maxRateBuckets {
+			rl.sweep(now)
+		}
+		b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), updated: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+
+	d := rateDecision{Allowed: b.tokens >= 1}
+	if d.Allowed {
+		b.tokens--
+	} else {
+		d.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = time.Duration((float64(limit.Burst) - b.tokens) * float64(perToken))
+	return d
+}
+
+// sweep discards the buckets that have been idle long enough to be full again.
+// Callers must hold rl.mu.
+func (rl *rateLimiter) sweep(now time.Time) {
+	for key, b := range rl.buckets {
+		if now.Sub(b.updated) >= b.limit.Per {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// rateLimitMiddleware returns a middleware that limits the requests of every client IP per route group,
+// as configured by rateLimits. Requests over the limit are rejected with status code 429
+// (Too Many Requests) and a Retry-After header. Route groups without a limit are not limited.
+// Every response of a limited route group carries X-RateLimit-Limit, X-RateLimit-Remaining, and
+// X-RateLimit-Reset headers: the burst size, the requests left, and the seconds until the full
+// burst is available again, so that clients can slow down before they are rejected.
+func rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group := rateGroup(c)
+		limit, ok := rateLimits[group]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		d := limiter.allow(group+"|"+c.ClientIP(), limit, time.Now())
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(d.Reset.Seconds()))))
+
+		if !d.Allowed {
+			setRetryAfter(c, d.RetryAfter)
+			respondError(c, http.StatusTooManyRequests, "rate limit of "+limit.String()+" for "+group+" exceeded")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestRateLimitIgnoresForwardedForFromUntrustedPeers(t *testing.T) {
+	t.Setenv("RATE_LIMITS", "writes=1/h")
+	router := newTestRouter(t)
+
+	for i, spoofed := range []string{"203.0.113.1", "203.0.113.2"} {
+		w := serve(router, http.MethodPost, "/books", `{"id":"spoof`+spoofed+`","title":"t"}`, "X-Forwarded-For", spoofed)
+		want := http.StatusCreated
+		if i > 0 {
+			want = http.StatusTooManyRequests
+		}
+		expectStatus(t, w, want)
+	}
+}
+
+func TestRateLimitKeysOnForwardedForFromTrustedProxies(t *testing.T) {
+	t.Setenv("RATE_LIMITS", "writes=1/h")
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.0/24")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"a","title":"t"}`, "X-Forwarded-For", "203.0.113.1"), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"b","title":"t"}`, "X-Forwarded-For", "203.0.113.2"), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"c","title":"t"}`, "X-Forwarded-For", "203.0.113.1"), http.StatusTooManyRequests)
+}
+
+func TestRateLimitJittersRetryAfter(t *testing.T) {
+	t.Setenv("RATE_LIMITS", "writes=1/h")
+	t.Setenv("RETRY_AFTER_JITTER", "1m")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"t"}`), http.StatusCreated)
+	seen := map[int]bool{}
+	for i := 0; i < 20; i++ {
+		w := serve(router, http.MethodPost, "/books", `{"id":"6","title":"t"}`)
+		expectStatus(t, w, http.StatusTooManyRequests)
+		secs, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		if err != nil || secs < 3600 || secs > 3660 {
+			t.Fatalf("Retry-After = %q, want between 3600 and 3660 seconds", w.Header().Get("Retry-After"))
+		}
+		seen[secs] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Retry-After was %v for every rejection, want jittered values", seen)
+	}
+}
+
+func TestRateLimitHeadersCountDown(t *testing.T) {
+	t.Setenv("RATE_LIMITS", "reads=3/h")
+	router := newTestRouter(t)
+
+	for i, want := range []string{"2", "1", "0", "0"} {
+		w := serve(router, http.MethodGet, "/books/1", "")
+		status := http.StatusOK
+		if i == 3 {
+			status = http.StatusTooManyRequests
+		}
+		expectStatus(t, w, status)
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != want {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %s", i+1, got, want)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("request %d: X-RateLimit-Limit = %q, want 3", i+1, got)
+		}
+		if reset, err := strconv.Atoi(w.Header().Get("X-RateLimit-Reset")); err != nil || reset <= 0 || reset > 3600 {
+			t.Errorf("request %d: X-RateLimit-Reset = %q, want up to an hour", i+1, w.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+
+	w := serve(router, http.MethodPatch, "/books/1", `{"quantity":3}`)
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("X-RateLimit-Remaining"); got != "" {
+		t.Errorf("X-RateLimit-Remaining = %q on an unlimited write, want none", got)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRecentLimit is the number of books returned by getRecentBooks when 'limit' is not given.
+const defaultRecentLimit = 10
+
+// getRecentBooks returns the most recently added books of the tenant, newest first, for a
+// "new arrivals" shelf. The number of books is given by the 'limit' query parameter, an integer
+// between 1 and maxPerPage that defaults to defaultRecentLimit. It accepts the same search query
+// parameters as getBooks, and returns an empty list if there are no books.
+func getRecentBooks(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRecentLimit)))
+	if err != nil || limit < 1 || limit > maxPerPage {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("limit", "an integer between 1 and "+strconv.Itoa(maxPerPage)).Error())
+		return
+	}
+
+	result := filterBooks(c)
+	sortBooks(result, sortSpec{Field: "created_at", Desc: true})
+
+	respondJSON(c, http.StatusOK, result[:min(limit, len(result))])
+}
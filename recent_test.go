@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetRecentBooksNewestFirst(t *testing.T) {
+	clk := stopClock(t, time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC))
+	router := newTestRouter(t)
+
+	for _, id := range []string{"5", "6", "7"} {
+		clk.now = clk.now.Add(time.Hour)
+		expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"`+id+`","title":"Arrival `+id+`"}`), http.StatusCreated)
+	}
+
+	if got, want := listIDs(t, router, "/books/recent?limit=2"), []string{"7", "6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recent books = %v, want %v", got, want)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/recent?limit=0", ""), http.StatusBadRequest)
+
+	if got := listIDs(t, router, "/books/recent?title=nothing"); len(got) != 0 {
+		t.Errorf("recent books matching nothing = %v, want an empty list", got)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// availableForCheckout returns the number of copies of the book that may be checked out:
+// its quantity less the copies reserved for in-library use.
+func (b *book) availableForCheckout() quantity {
+	return max(b.Quantity-b.ReservedQuantity, 0)
+}
+
+// atFloor reports whether checking out another copy of the book would take its quantity below
+// its minimum quantity, e.g. the reference copy that always stays on the shelf.
+func (b *book) atFloor() bool {
+	return b.Quantity-1 < b.MinQuantity
+}
+
+// validateReserved returns an error if more copies of the book are reserved than it has.
+func (b *book) validateReserved() error {
+	if b.ReservedQuantity > b.Quantity {
+		return fmt.Errorf("reserved_quantity %d exceeds the quantity of %d", b.ReservedQuantity, b.Quantity)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the book with its available_for_checkout, which is derived from its
+// quantity and reserved quantity. An available_for_checkout sent by a client is ignored.
+func (b book) MarshalJSON() ([]byte, error) {
+	type plain book
+	p := plain(b)
+	p.AvailableForCheckout = b.availableForCheckout()
+	return json.Marshal(p)
+}
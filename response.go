@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Field naming styles of JSON responses.
+const (
+	fieldStyleSnake = "snake_case"
+	fieldStyleCamel = "camelCase"
+)
+
+// dataKeyedFields are the fields of responses whose object keys are data, such as book IDs,
+// rather than field names, and are therefore never renamed.
+var dataKeyedFields = map[string]bool{"mapping": true}
+
+// withCharset returns mediaType with the configured response charset as its charset parameter,
+// or mediaType alone if the charset is disabled.
+func withCharset(mediaType string) string {
+	if responseCharset == "" {
+		return mediaType
+	}
+	return mediaType + "; charset=" + responseCharset
+}
+
+// jsonContentType returns the Content-Type of JSON responses.
+func jsonContentType() string {
+	return withCharset("application/json")
+}
+
+// prettyPrint reports whether the response to the request should be indented JSON: as requested
+// by an "X-Pretty-Print: true" or "X-Pretty-Print: false" header, e.g. to debug a production server
+// with curl, and as configured by prettyJSON otherwise.
+func prettyPrint(c *gin.Context) bool {
+	if pretty, err := strconv.ParseBool(c.GetHeader("X-Pretty-Print")); err == nil {
+		return pretty
+	}
+	return prettyJSON
+}
+
+// respondJSON sends obj as JSON with the given status code and an explicit Content-Type carrying
+// the configured charset, for strict clients that require one. The JSON is indented if prettyPrint
+// says so. Field names follow the configured field style, as described by marshalJSON.
+func respondJSON(c *gin.Context, status int, obj any) {
+	data, err := marshalJSON(obj, prettyPrint(c))
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.Data(status, jsonContentType(), data)
+}
+
+// marshalJSON encodes v like json.Marshal, or like json.MarshalIndent with four spaces if indent is true.
+// The struct tags name fields in snake_case; if the camelCase field style is configured, the names of
+// all fields are converted, e.g. "created_at" to "createdAt".
+func marshalJSON(v any, indent bool) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if jsonFieldStyle == fieldStyleCamel {
+		var buf bytes.Buffer
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := camelizeKeys(dec, &buf, true); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+
+	if !indent {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "    "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// camelizeKeys copies the next JSON value from dec to out, converting the keys of its objects to
+// camelCase if rename is true. The keys of objects in dataKeyedFields are left as they are.
+// The order of the keys is preserved.
+func camelizeKeys(dec *json.Decoder, out *bytes.Buffer, rename bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		out.WriteByte('{')
+		for first := true; dec.More(); first = false {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key := keyTok.(string)
+			if !first {
+				out.WriteByte(',')
+			}
+			name := key
+			if rename {
+				name = snakeToCamel(key)
+			}
+			encoded, _ := json.Marshal(name)
+			out.Write(encoded)
+			out.WriteByte(':')
+			if err := camelizeKeys(dec, out, !dataKeyedFields[key]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case json.Delim('['):
+		out.WriteByte('[')
+		for first := true; dec.More(); first = false {
+			if !first {
+				out.WriteByte(',')
+			}
+			if err := camelizeKeys(dec, out, rename); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		encoded, err := json.Marshal(tok)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+		return nil
+	}
+
+	// Consume the closing delimiter.
+	_, err = dec.Token()
+	return err
+}
+
+// snakeToCamel converts a snake_case name to camelCase, e.g. "borrow_count" to "borrowCount".
+func snakeToCamel(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJSONContentTypeCharset(t *testing.T) {
+	for charset, want := range map[string]string{
+		"":      "application/json; charset=utf-8",
+		"UTF-8": "application/json; charset=UTF-8",
+		"none":  "application/json",
+	} {
+		t.Run(charset, func(t *testing.T) {
+			if charset != "" {
+				t.Setenv("RESPONSE_CHARSET", charset)
+			}
+			router := newTestRouter(t)
+
+			for _, path := range []string{"/books", "/books/1", "/books/42"} {
+				if got := serve(router, http.MethodGet, path, "").Header().Get("Content-Type"); got != want {
+					t.Errorf("Content-Type of %s = %q, want %q", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONFieldStyle(t *testing.T) {
+	for style, want := range map[string][]string{
+		fieldStyleSnake: {`"borrow_count"`, `"created_at"`, `"available_for_checkout"`},
+		fieldStyleCamel: {`"borrowCount"`, `"createdAt"`, `"availableForCheckout"`},
+	} {
+		t.Run(style, func(t *testing.T) {
+			t.Setenv("JSON_FIELD_STYLE", style)
+			router := newTestRouter(t)
+
+			body := serve(router, http.MethodGet, "/books/1", "").Body.String()
+			for _, key := range want {
+				if !strings.Contains(body, key+":") {
+					t.Errorf("book lacks %s: %s", key, body)
+				}
+			}
+		})
+	}
+}
+
+func TestCamelCaseKeepsDataKeys(t *testing.T) {
+	t.Setenv("JSON_FIELD_STYLE", fieldStyleCamel)
+	if err := loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := marshalJSON(reindexResponse{Message: "success", Mapping: map[string]string{"old_id": "new_id"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"message":"success","mapping":{"old_id":"new_id"}}`; got != want {
+		t.Errorf("marshalJSON = %s, want %s", got, want)
+	}
+}
+
+func TestPrettyPrintHeaderOverridesConfig(t *testing.T) {
+	for _, configured := range []bool{false, true} {
+		t.Run(fmt.Sprint("PRETTY_JSON=", configured), func(t *testing.T) {
+			t.Setenv("PRETTY_JSON", strconv.FormatBool(configured))
+			router := newTestRouter(t)
+
+			for _, path := range []string{"/books", "/books/1", "/books/42"} {
+				for _, header := range []string{"", "true", "false"} {
+					want := configured
+					if header != "" {
+						want = header == "true"
+					}
+					w := serve(router, http.MethodGet, path, "", "X-Pretty-Print", header)
+					if indented := strings.Contains(w.Body.String(), "\n"); indented != want {
+						t.Errorf("GET %s with X-Pretty-Print %q: indented = %t, want %t; body: %s", path, header, indented, want, w.Body.String())
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jitteredRetryAfter returns d plus a random duration between 0 and jitter, so that clients
+// told to retry at the same time spread their retries out instead of returning in a herd.
+func jitteredRetryAfter(d, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(int64(jitter)+1))
+}
+
+// setRetryAfter sets the Retry-After response header to d, plus up to retryAfterJitter,
+// rounded up to whole seconds. It is shared by every middleware that asks clients to come back later.
+func setRetryAfter(c *gin.Context, d time.Duration) {
+	d = jitteredRetryAfter(d, retryAfterJitter)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxQueryDepth limits how deeply search queries may nest "and"/"or" groups.
+const maxQueryDepth = 10
+
+// searchQuery is a node of the query language accepted by searchBooks. A node is either a
+// group combining its children with "and" or "or", or a condition comparing a field to a value:
+//
+//	{"and": [
+//	  {"field": "author", "op": "eq", "value": "Mr. Golang"},
+//	  {"or": [
+//	    {"field": "quantity", "op": "gte", "value": 10},
+//	    {"field": "title", "op": "contains", "value": "pointers"}
+//	  ]}
+//	]}
+//
+// The string fields id, title, author, and isbn support "eq" and the case-insensitive "contains";
+// the quantity field supports "eq", "gte", and "lte".
+type searchQuery struct {
+	And   []searchQuery   `json:"and"`
+	Or    []searchQuery   `json:"or"`
+	Field string          `json:"field"`
+	Op    string          `json:"op"`
+	Value json.RawMessage `json:"value"`
+}
+
+// bookPredicate reports whether a book matches a search query.
+type bookPredicate func(b *book) bool
+
+// stringFields maps the string fields that can be searched to their accessors.
+var stringFields = map[string]func(b *book) string{
+	"id":     func(b *book) string { return b.ID },
+	"title":  func(b *book) string { return b.Title },
+	"author": func(b *book) string { return b.Author },
+	"isbn":   func(b *book) string { return b.ISBN },
+}
+
+// searchResults is the response of searchBooks. Total is the number of matching books, of which
+// only the first maxSearchResults are listed in Books; Truncated reports whether any were left out.
+type searchResults struct {
+	Books     []book `json:"books"`
+	Total     int    `json:"total"`
+	Truncated bool   `json:"truncated"`
+}
+
+// searchBooks returns the tenant's books matching the query in the JSON payload.
+// See searchQuery for the query language.
+// At most maxSearchResults books are returned; if more match, the response is marked as truncated
+// so that clients know to narrow their query.
+// It returns a 400 status code if the query uses an unknown field or operator, or is otherwise malformed.
+func searchBooks(c *gin.Context) {
+	var query searchQuery
+
+	if err := c.ShouldBindJSON(&query); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	match, err := query.compile(0)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "invalid query: "+err.Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	result := searchResults{Books: []book{}}
+	for i := range lib.books {
+		if !match(&lib.books[i]) {
+			continue
+		}
+		result.Total++
+		if maxSearchResults > 0 && len(result.Books) >= maxSearchResults {
+			result.Truncated = true
+			continue
+		}
+		result.Books = append(result.Books, lib.books[i])
+	}
+
+	respondJSON(c, http.StatusOK, result)
+}
+
+// parsePrecondition parses a single condition written as "field:op:value", e.g. "quantity:eq:0",
+// with the fields and operators of searchQuery, into a predicate.
+func parsePrecondition(s string) (bookPredicate, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("'%s' must be of the form field:op:value", s)
+	}
+
+	value, err := json.Marshal(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	return searchQuery{Field: parts[0], Op: parts[1], Value: value}.compileCondition()
+}
+
+// compile validates the query and turns it into a predicate.
+func (q searchQuery) compile(depth int) (bookPredicate, error) {
+	if depth > maxQueryDepth {
+		return nil, fmt.Errorf("query is nested more than %d levels deep", maxQueryDepth)
+	}
+
+	kinds := 0
+	if q.And != nil {
+		kinds++
+	}
+	if q.Or != nil {
+		kinds++
+	}
+	if q.Field != "" || q.Op != "" || q.Value != nil {
+		kinds++
+	}
+	if kinds != 1 {
+		return nil, fmt.Errorf("each query node must be exactly one of 'and', 'or', or a field condition")
+	}
+
+	switch {
+	case q.And != nil:
+		return compileGroup(q.And, depth, true)
+	case q.Or != nil:
+		return compileGroup(q.Or, depth, false)
+	default:
+		return q.compileCondition()
+	}
+}
+
+// compileGroup compiles the children of an "and" (all true) or "or" (any true) group.
+func compileGroup(children []searchQuery, depth int, all bool) (bookPredicate, error) {
+	if len(children) == 0 {
+		return nil, fmt.Errorf("'and' and 'or' groups must not be empty")
+	}
+
+	preds := make([]bookPredicate, len(children))
+	for i, child := range children {
+		pred, err := child.compile(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		preds[i] = pred
+	}
+
+	return func(b *book) bool {
+		for _, pred := range preds {
+			if pred(b) != all {
+				return !all
+			}
+		}
+		return all
+	}, nil
+}
+
+// compileCondition compiles a single field comparison.
+func (q searchQuery) compileCondition() (bookPredicate, error) {
+	if q.Value == nil {
+		return nil, fmt.Errorf("condition on field '%s' is missing a 'value'", q.Field)
+	}
+
+	if q.Field == "quantity" {
+		var want quantity
+		if err := json.Unmarshal(q.Value, &want); err != nil {
+			return nil, fmt.Errorf("value for field 'quantity': %v", err)
+		}
+
+		switch q.Op {
+		case "eq":
+			return func(b *book) bool { return b.Quantity == want }, nil
+		case "gte":
+			return func(b *book) bool { return b.Quantity >= want }, nil
+		case "lte":
+			return func(b *book) bool { return b.Quantity <= want }, nil
+		}
+		return nil, fmt.Errorf("unknown operator '%s' for field 'quantity', expected one of eq, gte, lte", q.Op)
+	}
+
+	get, ok := stringFields[q.Field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field '%s', expected one of id, title, author, isbn, quantity", q.Field)
+	}
+
+	var want string
+	if err := json.Unmarshal(q.Value, &want); err != nil {
+		return nil, fmt.Errorf("value for field '%s' must be a string", q.Field)
+	}
+
+	switch q.Op {
+	case "eq":
+		return func(b *book) bool { return get(b) == want }, nil
+	case "contains":
+		want = strings.ToLower(want)
+		return func(b *book) bool { return strings.Contains(strings.ToLower(get(b)), want) }, nil
+	}
+	return nil, fmt.Errorf("unknown operator '%s' for field '%s', expected one of eq, contains", q.Op, q.Field)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// searchIDs returns the IDs of the books in a searchResults response.
+func searchIDs(t *testing.T, router http.Handler, query string) []string {
+	t.Helper()
+
+	w := serve(router, http.MethodPost, "/books/search", query)
+	expectStatus(t, w, http.StatusOK)
+	ids := []string{}
+	for _, b := range decode[searchResults](t, w).Books {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestSearchBooksCombinesConditions(t *testing.T) {
+	router := newTestRouter(t)
+
+	got := searchIDs(t, router, `{"and":[
+		{"field":"title","op":"contains","value":"GOLANG"},
+		{"field":"quantity","op":"gte","value":30}
+	]}`)
+	if len(got) != 2 || got[0] != "3" || got[1] != "4" {
+		t.Errorf("AND query matched %v, want [3 4]", got)
+	}
+
+	got = searchIDs(t, router, `{"and":[
+		{"field":"quantity","op":"lte","value":30},
+		{"or":[
+			{"field":"author","op":"eq","value":"Mr. Golang"},
+			{"field":"title","op":"contains","value":"routers"}
+		]}
+	]}`)
+	if len(got) != 2 || got[0] != "1" || got[1] != "3" {
+		t.Errorf("nested OR query matched %v, want [1 3]", got)
+	}
+}
+
+func TestSearchBooksRejectsInvalidQueries(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, query := range []string{
+		`{"field":"publisher","op":"eq","value":"x"}`,
+		`{"field":"title","op":"gte","value":"x"}`,
+		`{"field":"quantity","op":"contains","value":1}`,
+		`{"field":"quantity","op":"eq","value":"many"}`,
+	} {
+		expectStatus(t, serve(router, http.MethodPost, "/books/search", query), http.StatusBadRequest)
+	}
+}
+
+func TestSearchBooksTruncatesResults(t *testing.T) {
+	t.Setenv("MAX_SEARCH_RESULTS", "2")
+	router := newTestRouter(t)
+
+	query := `{"field":"title","op":"contains","value":"golang"}`
+	w := serve(router, http.MethodPost, "/books/search", query)
+	expectStatus(t, w, http.StatusOK)
+	if res := decode[searchResults](t, w); len(res.Books) != 2 || res.Total != 3 || !res.Truncated {
+		t.Errorf("%d books of %d, truncated %t; want 2 of 3, truncated", len(res.Books), res.Total, res.Truncated)
+	}
+
+	w = serve(router, http.MethodPost, "/books/search", `{"field":"author","op":"eq","value":"Mr. Router"}`)
+	if res := decode[searchResults](t, w); len(res.Books) != 1 || res.Total != 1 || res.Truncated {
+		t.Errorf("%d books of %d, truncated %t; want 1 of 1, not truncated", len(res.Books), res.Total, res.Truncated)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// snapshot is a named copy of a tenant's books, taken by createSnapshot.
+type snapshot struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Books     []book    `json:"books"`
+}
+
+// snapshotInfo describes a snapshot without its books.
+type snapshotInfo struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Books     int       `json:"books"`
+}
+
+// bookChange is a book whose fields differ between two snapshots.
+type bookChange struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+	From   book     `json:"from"`
+	To     book     `json:"to"`
+}
+
+// snapshotDiff is the response of diffSnapshots.
+type snapshotDiff struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Added   []book       `json:"added"`
+	Removed []book       `json:"removed"`
+	Changed []bookChange `json:"changed"`
+}
+
+var (
+	// snapshotsMu guards snapshots.
+	snapshotsMu sync.Mutex
+	// snapshots holds the snapshots of every tenant, keyed by tenant and snapshot name.
+	snapshots = map[string]map[string]snapshot{}
+)
+
+// createSnapshot captures the tenant's books under the name given in the path, for later
+// comparison with diffSnapshots. It returns the snapshot's name, creation time, and number of
+// books with status code 201 (Created), or a 409 status code if the name is already taken.
+func createSnapshot(c *gin.Context) {
+	tenant := currentTenant(c)
+	lib := currentLibrary(c)
+	name := c.Param("name")
+
+	storeMu.RLock()
+	snap := snapshot{Name: name, CreatedAt: serverClock.Now(), Books: cloneBooks(lib.books)}
+	storeMu.RUnlock()
+
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+
+	if _, exists := snapshots[tenant][name]; exists {
+		respondError(c, http.StatusConflict, "snapshot '"+name+"' already exists")
+		return
+	}
+	if snapshots[tenant] == nil {
+		snapshots[tenant] = map[string]snapshot{}
+	}
+	snapshots[tenant][name] = snap
+
+	respondJSON(c, http.StatusCreated, snapshotInfo{Name: name, CreatedAt: snap.CreatedAt, Books: len(snap.Books)})
+}
+
+// diffSnapshots compares the tenant's snapshots named by the 'from' and 'to' query parameters
+// by book ID. It returns the books only in 'to' as added, the books only in 'from' as removed,
+// and the books whose fields differ as changed, naming the fields; each list is sorted by ID.
+// It returns a 400 status code if either parameter is missing, or a 404 status code if either
+// snapshot does not exist.
+func diffSnapshots(c *gin.Context) {
+	fromName, toName := c.Query("from"), c.Query("to")
+	if fromName == "" || toName == "" {
+		respondError(c, http.StatusBadRequest, "query parameters 'from' and 'to' are required")
+		return
+	}
+
+	tenant := currentTenant(c)
+
+	snapshotsMu.Lock()
+	from, fromOK := snapshots[tenant][fromName]
+	to, toOK := snapshots[tenant][toName]
+	snapshotsMu.Unlock()
+
+	if !fromOK {
+		respondError(c, http.StatusNotFound, "snapshot '"+fromName+"' not found")
+		return
+	}
+	if !toOK {
+		respondError(c, http.StatusNotFound, "snapshot '"+toName+"' not found")
+		return
+	}
+
+	diff := snapshotDiff{From: fromName, To: toName, Added: []book{}, Removed: []book{}, Changed: []bookChange{}}
+
+	before := make(map[string]book, len(from.Books))
+	for _, b := range from.Books {
+		before[b.ID] = b
+	}
+	after := make(map[string]book, len(to.Books))
+	for _, b := range to.Books {
+		after[b.ID] = b
+
+		old, ok := before[b.ID]
+		if !ok {
+			diff.Added = append(diff.Added, b)
+			continue
+		}
+		if fields := changedFields(old, b); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, bookChange{ID: b.ID, Fields: fields, From: old, To: b})
+		}
+	}
+	for _, b := range from.Books {
+		if _, ok := after[b.ID]; !ok {
+			diff.Removed = append(diff.Removed, b)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].ID < diff.Added[j].ID })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].ID < diff.Removed[j].ID })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ID < diff.Changed[j].ID })
+
+	respondJSON(c, http.StatusOK, diff)
+}
+
+// changedFields returns the JSON names of the fields that differ between two versions of a book.
+// The modification time is left out, since it changes along with any other field.
+func changedFields(a, b book) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+
+	add("title", a.Title != b.Title)
+	add("author", a.Author != b.Author)
+	add("isbn", a.ISBN != b.ISBN)
+	add("category", a.Category != b.Category)
+	add("tags", !slices.Equal(a.Tags, b.Tags))
+	add("cover_url", a.CoverURL != b.CoverURL)
+	add("quantity", a.Quantity != b.Quantity)
+	add("reserved_quantity", a.ReservedQuantity != b.ReservedQuantity)
+	add("min_quantity", a.MinQuantity != b.MinQuantity)
+	add("loan_days", a.LoanDays != b.LoanDays)
+	add("available_from", a.AvailableFrom != b.AvailableFrom)
+	add("available_until", a.AvailableUntil != b.AvailableUntil)
+	add("copies", !slices.Equal(a.Copies, b.Copies))
+	add("borrow_count", a.BorrowCount != b.BorrowCount)
+	add("created_at", !a.CreatedAt.Equal(b.CreatedAt))
+	return fields
+}
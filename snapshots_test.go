@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newTestRouter(t)
+	admin := func(method, path string) *httptest.ResponseRecorder {
+		return serve(router, method, path, "", "X-API-Key", "secret")
+	}
+
+	expectStatus(t, admin(http.MethodPost, "/admin/snapshots/before"), http.StatusCreated)
+	expectStatus(t, admin(http.MethodPost, "/admin/snapshots/before"), http.StatusConflict)
+
+	expectStatus(t, serve(router, http.MethodPatch, "/books/2", `{"title":"Goroutines, 2nd ed.","quantity":5}`), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodDelete, "/books", `{"ids":["3"]}`, "X-API-Key", "secret"), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Channels"}`), http.StatusCreated)
+	expectStatus(t, admin(http.MethodPost, "/admin/snapshots/after"), http.StatusCreated)
+
+	w := admin(http.MethodGet, "/admin/snapshots/diff?from=before&to=after")
+	expectStatus(t, w, http.StatusOK)
+	diff := decode[snapshotDiff](t, w)
+	if len(diff.Added) != 1 || diff.Added[0].ID != "5" {
+		t.Errorf("added = %+v, want book 5", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != "3" {
+		t.Errorf("removed = %+v, want book 3", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].ID != "2" || !reflect.DeepEqual(diff.Changed[0].Fields, []string{"title", "quantity"}) {
+		t.Errorf("changed = %+v, want the title and quantity of book 2", diff.Changed)
+	}
+
+	expectStatus(t, admin(http.MethodGet, "/admin/snapshots/diff?from=before"), http.StatusBadRequest)
+	expectStatus(t, admin(http.MethodGet, "/admin/snapshots/diff?from=before&to=missing"), http.StatusNotFound)
+}
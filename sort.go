@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortSpec describes how a list of books is ordered: by which field and in which direction.
+type sortSpec struct {
+	Field string
+	Desc  bool
+}
+
+// String returns the specification in the "field:direction" form accepted by parseSort,
+// or an empty string for insertion order.
+func (s sortSpec) String() string {
+	switch {
+	case s.Field == "":
+		return ""
+	case s.Desc:
+		return s.Field + ":desc"
+	}
+	return s.Field + ":asc"
+}
+
+// bookLess compares two books by a single field.
+type bookLess func(a, b *book) bool
+
+// sortFields maps the fields books can be sorted by to their comparison functions.
+var sortFields = map[string]bookLess{
+	"id":         func(a, b *book) bool { return a.ID < b.ID },
+	"title":      func(a, b *book) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) },
+	"author":     func(a, b *book) bool { return strings.ToLower(a.Author) < strings.ToLower(b.Author) },
+	"isbn":       func(a, b *book) bool { return a.ISBN < b.ISBN },
+	"quantity":   func(a, b *book) bool { return a.Quantity < b.Quantity },
+	"created_at": func(a, b *book) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"updated_at": func(a, b *book) bool { return a.UpdatedAt.Before(b.UpdatedAt) },
+}
+
+// parseSort parses a sort specification of the form "field" or "field:asc|desc".
+// An empty string yields the zero sortSpec, which keeps books in insertion order.
+func parseSort(s string) (sortSpec, error) {
+	if s == "" {
+		return sortSpec{}, nil
+	}
+
+	field, dir, _ := strings.Cut(s, ":")
+	if _, ok := sortFields[field]; !ok {
+		return sortSpec{}, fmt.Errorf("unknown sort field '%s'", field)
+	}
+
+	switch dir {
+	case "", "asc":
+		return sortSpec{Field: field}, nil
+	case "desc":
+		return sortSpec{Field: field, Desc: true}, nil
+	}
+	return sortSpec{}, fmt.Errorf("unknown sort direction '%s', expected 'asc' or 'desc'", dir)
+}
+
+// sortBooks sorts list in place according to spec. Books that compare equal are ordered
+// by ID so that the order is fully deterministic.
+func sortBooks(list []book, spec sortSpec) {
+	if spec.Field == "" {
+		return
+	}
+
+	less := sortFields[spec.Field]
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := &list[i], &list[j]
+		if spec.Desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return list[i].ID < list[j].ID
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// listIDs returns the IDs of the books listed by GET path, in order.
+func listIDs(t *testing.T, router http.Handler, path string) []string {
+	t.Helper()
+
+	w := serve(router, http.MethodGet, path, "")
+	expectStatus(t, w, http.StatusOK)
+	ids := []string{}
+	for _, b := range decode[[]book](t, w) {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+func TestGetBooksAppliesDefaultSort(t *testing.T) {
+	router := newTestRouter(t)
+	if got, want := listIDs(t, router, "/books"), []string{"1", "2", "3", "4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("without a default sort: %v, want insertion order %v", got, want)
+	}
+
+	t.Setenv("DEFAULT_SORT", "title:asc")
+	router = newTestRouter(t)
+	if got, want := listIDs(t, router, "/books"), []string{"4", "1", "3", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with DEFAULT_SORT=title:asc: %v, want %v", got, want)
+	}
+	if got, want := listIDs(t, router, "/books?sort=quantity:desc"), []string{"4", "3", "2", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with an explicit sort: %v, want %v", got, want)
+	}
+
+	t.Setenv("DEFAULT_SORT", "publisher")
+	if err := loadConfig(); err == nil {
+		t.Error("loadConfig accepted an unknown DEFAULT_SORT field")
+	}
+}
+
+func TestSortBooksBreaksTiesByID(t *testing.T) {
+	list := []book{{ID: "b", Quantity: 1}, {ID: "c", Quantity: 0}, {ID: "a", Quantity: 1}}
+	sortBooks(list, sortSpec{Field: "quantity", Desc: true})
+	if got := []string{list[0].ID, list[1].ID, list[2].ID}; !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("sorted IDs = %v, want [a b c]", got)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bookStats summarises the tenant's stock, as returned by getBookStats.
+// Owned is the number of copies the library owns: those available plus those checked out.
+// CheckoutRatio is the fraction of owned copies that are checked out, or 0 if none are owned.
+type bookStats struct {
+	Books         int       `json:"books"`
+	Available     int       `json:"available"`
+	CheckedOut    int       `json:"checked_out"`
+	Owned         int       `json:"owned"`
+	CheckoutRatio float64   `json:"checkout_ratio"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
+
+// statsCache holds the stats of every tenant as last computed by runStatsWorker.
+var statsCache struct {
+	sync.RWMutex
+	byTenant map[string]bookStats
+}
+
+// computeStats returns the library's stats as of now.
+// Callers must hold storeMu.
+func (l *library) computeStats(now time.Time) bookStats {
+	stats := bookStats{Books: len(l.books), CheckedOut: len(l.checkouts), ComputedAt: now}
+	for _, b := range l.books {
+		stats.Available += int(b.Quantity)
+	}
+
+	stats.Owned = stats.Available + stats.CheckedOut
+	if stats.Owned > 0 {
+		stats.CheckoutRatio = float64(stats.CheckedOut) / float64(stats.Owned)
+	}
+	return stats
+}
+
+// refreshStats recomputes the stats of every tenant into statsCache. It returns the storeChanged
+// channel of the store it computed them from, which is closed once they are out of date.
+func refreshStats() <-chan struct{} {
+	now := serverClock.Now()
+
+	storeMu.RLock()
+	changed := storeChanged
+	byTenant := make(map[string]bookStats, len(libraries))
+	for tenant, lib := range libraries {
+		byTenant[tenant] = lib.computeStats(now)
+	}
+	storeMu.RUnlock()
+
+	statsCache.Lock()
+	statsCache.byTenant = byTenant
+	statsCache.Unlock()
+
+	return changed
+}
+
+// runStatsWorker keeps statsCache up to date, recomputing it after every write to the store,
+// until ctx is cancelled.
+func runStatsWorker(ctx context.Context) {
+	for {
+		changed := refreshStats()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+// getBookStats returns aggregate stock figures of the tenant for utilization reporting: the
+// number of books, the copies available (the sum of all quantities), and the copies checked out
+// (the outstanding checkouts), together with the time they were computed at.
+// The stats are served from statsCache, so they may lag behind a write by the time it takes the
+// stats worker to catch up. Tenants the worker has not seen yet get freshly computed stats.
+func getBookStats(c *gin.Context) {
+	statsCache.RLock()
+	stats, ok := statsCache.byTenant[currentTenant(c)]
+	statsCache.RUnlock()
+
+	if !ok {
+		lib := currentLibrary(c)
+
+		storeMu.RLock()
+		stats = lib.computeStats(serverClock.Now())
+		storeMu.RUnlock()
+	}
+
+	respondJSON(c, http.StatusOK, stats)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetBookStatsSplitsAvailableAndCheckedOut(t *testing.T) {
+	router := newTestRouter(t)
+	for _, path := range []string{"/checkout?id=1&user=ann", "/checkout?id=2&user=ann", "/checkout?id=2&user=bob"} {
+		expectStatus(t, serve(router, http.MethodPatch, path, ""), http.StatusOK)
+	}
+
+	w := serve(router, http.MethodGet, "/books/stats", "")
+	expectStatus(t, w, http.StatusOK)
+	stats := decode[bookStats](t, w)
+	if stats.Books != 4 || stats.Available != 89 || stats.CheckedOut != 3 || stats.Owned != 92 || stats.CheckoutRatio != 3.0/92 {
+		t.Errorf("stats = %+v, want 89 of 92 copies available and 3 checked out", stats)
+	}
+
+	// Once the worker has computed them, the stats are served from the cache until it catches up.
+	refreshStats()
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2&user=bob", ""), http.StatusOK)
+	if stats := decode[bookStats](t, serve(router, http.MethodGet, "/books/stats", "")); stats.CheckedOut != 3 {
+		t.Errorf("checked out = %d from the cache, want 3", stats.CheckedOut)
+	}
+	refreshStats()
+	if stats := decode[bookStats](t, serve(router, http.MethodGet, "/books/stats", "")); stats.Available != 90 || stats.CheckedOut != 2 {
+		t.Errorf("stats = %+v after the refresh, want 90 available and 2 checked out", stats)
+	}
+}
+
+func TestStatsWorkerRecomputesAfterWrites(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clk := stopClock(t, start)
+	router := newTestRouter(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runStatsWorker(ctx)
+
+	stats := func() bookStats {
+		statsCache.RLock()
+		defer statsCache.RUnlock()
+		return statsCache.byTenant[defaultTenant]
+	}
+	for deadline := time.Now().Add(5 * time.Second); stats().Books != 4; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("stats worker never computed the stats")
+		}
+	}
+
+	clk.now = start.Add(time.Minute)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+	for deadline := time.Now().Add(5 * time.Second); stats().CheckedOut != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("stats worker did not recompute the stats after a checkout")
+		}
+	}
+
+	w := serve(router, http.MethodGet, "/books/stats", "")
+	if got := decode[bookStats](t, w); got.CheckedOut != 1 || got.Available != 91 || !got.ComputedAt.Equal(clk.now) {
+		t.Errorf("stats = %+v, want the checkout counted, computed at %s", got, clk.now)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getOutOfStockBooks returns every book of the tenant with a quantity of 0, for reordering,
+// sorted by title. With 'by_demand=true', the most borrowed books are listed first instead,
+// so that high-demand titles stand out. It returns an empty list if nothing is out of stock.
+func getOutOfStockBooks(c *gin.Context) {
+	byDemand, err := strconv.ParseBool(c.DefaultQuery("by_demand", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errInvalidQuery("by_demand", "a boolean").Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	result := []book{}
+	for i, b := range lib.books {
+		if b.Quantity <= 0 {
+			result = append(result, cloneBooks(lib.books[i:i+1])...)
+		}
+	}
+	storeMu.RUnlock()
+
+	sortBooks(result, sortSpec{Field: "title"})
+	if byDemand {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].BorrowCount > result[j].BorrowCount
+		})
+	}
+
+	respondJSON(c, http.StatusOK, result)
+}
+
+// getNeverBorrowedBooks returns every book of the tenant that has never been checked out, to
+// identify dead stock, oldest first. The optional 'older_than_days' query parameter, a non-negative
+// integer, restricts the list to books created more than that many days ago.
+func getNeverBorrowedBooks(c *gin.Context) {
+	var cutoff time.Time
+	if v, ok := c.GetQuery("older_than_days"); ok {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			respondError(c, http.StatusBadRequest, errInvalidQuery("older_than_days", "a non-negative integer").Error())
+			return
+		}
+		cutoff = serverClock.Now().AddDate(0, 0, -days)
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	result := []book{}
+	for i, b := range lib.books {
+		if b.BorrowCount == 0 && (cutoff.IsZero() || b.CreatedAt.Before(cutoff)) {
+			result = append(result, cloneBooks(lib.books[i:i+1])...)
+		}
+	}
+	storeMu.RUnlock()
+
+	sortBooks(result, sortSpec{Field: "created_at"})
+
+	respondJSON(c, http.StatusOK, result)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetOutOfStockBooks(t *testing.T) {
+	router := newTestRouter(t)
+	if got := listIDs(t, router, "/books/out-of-stock"); len(got) != 0 {
+		t.Errorf("out of stock = %v, want an empty list", got)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Algorithms","quantity":0}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2", ""), http.StatusOK)
+
+	if got, want := listIDs(t, router, "/books/out-of-stock"), []string{"5", "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("out of stock = %v, want %v sorted by title", got, want)
+	}
+	if got, want := listIDs(t, router, "/books/out-of-stock?by_demand=true"), []string{"1", "5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("out of stock by demand = %v, want %v", got, want)
+	}
+}
+
+func TestGetNeverBorrowedBooks(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clk := stopClock(t, start)
+	router := newTestRouter(t)
+
+	clk.now = start.AddDate(0, 0, 10)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Newer","quantity":1}`), http.StatusCreated)
+	clk.now = start.AddDate(0, 0, 1)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Older","quantity":1}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2", ""), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodPatch, "/return?id=2", ""), http.StatusOK)
+
+	clk.now = start.AddDate(0, 0, 40)
+	if got, want := listIDs(t, router, "/books/never-borrowed"), []string{"1", "3", "4", "6", "5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("never borrowed = %v, want %v oldest first", got, want)
+	}
+	if got, want := listIDs(t, router, "/books/never-borrowed?older_than_days=35"), []string{"1", "3", "4", "6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("never borrowed, older than 35 days = %v, want %v", got, want)
+	}
+	expectStatus(t, serve(router, http.MethodGet, "/books/never-borrowed?older_than_days=-1", ""), http.StatusBadRequest)
+}
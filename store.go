@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTenant is the tenant used for requests that do not carry an X-Tenant-ID header.
+const defaultTenant = "default"
+
+// tenantKey is the gin.Context key under which tenantMiddleware stores the request's tenant ID.
+const tenantKey = "tenant"
+
+// library holds the books and outstanding checkouts of a single tenant.
+type library struct {
+	books     []book
+	checkouts []checkout
+}
+
+// storeMu guards libraries and their contents, which are shared between request handlers and background workers.
+var storeMu sync.RWMutex
+
+// storeGeneration is incremented every time the store is modified, so that cached responses
+// derived from the store can tell whether they are still current.
+var storeGeneration atomic.Uint64
+
+// storeChanged is closed and replaced every time the store is modified, waking up everyone
+// waiting for the store to change. It is guarded by storeMu.
+var storeChanged = make(chan struct{})
+
+// markStoreChanged increments storeGeneration and signals storeChanged. Every write that modifies
+// the store calls it before releasing storeMu, which it must hold for writing; failed writes and
+// writes that leave the store as it was do not, so that they invalidate no caches and wake up no watchers.
+func markStoreChanged() {
+	storeGeneration.Add(1)
+	close(storeChanged)
+	storeChanged = make(chan struct{})
+}
+
+// libraries holds the library of every tenant, keyed by tenant ID.
+// The default tenant starts out with the seed books, every other tenant with an empty library.
+var libraries = map[string]*library{
+	defaultTenant: newLibrary(seedBooks),
+}
+
+// newLibrary returns a library holding a copy of the given books and no checkouts.
+// Books without timestamps are stamped as created and last modified now.
+func newLibrary(seed []book) *library {
+	now := serverClock.Now()
+	lib := &library{books: append([]book{}, seed...), checkouts: []checkout{}}
+	for i := range lib.books {
+		if lib.books[i].CreatedAt.IsZero() {
+			lib.books[i].CreatedAt = now
+		}
+		if lib.books[i].UpdatedAt.IsZero() {
+			lib.books[i].UpdatedAt = lib.books[i].CreatedAt
+		}
+	}
+	return lib
+}
+
+// libraryFor returns the library of the given tenant. If the tenant has none yet and create is
+// set, an empty one is created and kept; otherwise an empty library is returned that is not kept,
+// so that reads for unknown tenants do not take up memory.
+// Looking up an existing library only takes a read lock, and creating an empty one does not count
+// as a change to the store.
+func libraryFor(tenant string, create bool) *library {
+	storeMu.RLock()
+	lib, ok := libraries[tenant]
+	storeMu.RUnlock()
+	if ok {
+		return lib
+	}
+	if !create {
+		return newLibrary(nil)
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	lib, ok = libraries[tenant]
+	if !ok {
+		lib = newLibrary(nil)
+		libraries[tenant] = lib
+	}
+	return lib
+}
+
+// currentTenant returns the ID of the tenant the request was made for.
+func currentTenant(c *gin.Context) string {
+	return c.GetString(tenantKey)
+}
+
+// currentLibrary returns the library of the tenant the request was made for. Only requests that
+// may modify the store create the library of a new tenant; see libraryFor.
+func currentLibrary(c *gin.Context) *library {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return libraryFor(currentTenant(c), false)
+	default:
+		return libraryFor(currentTenant(c), true)
+	}
+}
+
+// integrityViolations describes every inconsistency in the library: books without an ID,
+// duplicate IDs, negative quantities or loan periods, copies with duplicate barcodes or not matching
+// the quantity, and checkouts of unknown books.
+// Callers must hold storeMu.
+func (l *library) integrityViolations() []string {
+	var violations []string
+
+	ids := make(map[string]bool, len(l.books))
+	for _, b := range l.books {
+		switch {
+		case b.ID == "":
+			violations = append(violations, fmt.Sprintf("book %q has no ID", b.Title))
+			continue
+		case ids[b.ID]:
+			violations = append(violations, fmt.Sprintf("duplicate book ID %q", b.ID))
+		}
+		ids[b.ID] = true
+
+		if b.Quantity < 0 {
+			violations = append(violations, fmt.Sprintf("book %q has a negative quantity", b.ID))
+		}
+		if b.LoanDays < 0 {
+			violations = append(violations, fmt.Sprintf("book %q has a negative loan period", b.ID))
+		}
+		if err := b.validateCopies(); err != nil {
+			violations = append(violations, fmt.Sprintf("book %q has a %s", b.ID, err))
+		}
+		if b.tracksCopies() && b.Quantity != b.availableCopies() {
+			violations = append(violations, fmt.Sprintf("book %q has a quantity that does not match its available copies", b.ID))
+		}
+	}
+
+	for _, co := range l.checkouts {
+		if !ids[co.BookID] {
+			violations = append(violations, fmt.Sprintf("checkout refers to unknown book %q", co.BookID))
+		}
+	}
+
+	return violations
+}
+
+// verifyStoreIntegrity checks the libraries of all tenants for integrity violations and logs
+// each one. If strict is true and any violation was found, it returns an error so that the
+// server can refuse to start on corrupt data.
+func verifyStoreIntegrity(strict bool) error {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	tenants := make([]string, 0, len(libraries))
+	for tenant := range libraries {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	count := 0
+	for _, tenant := range tenants {
+		for _, v := range libraries[tenant].integrityViolations() {
+			slog.Warn("store integrity violation", "tenant", tenant, "violation", v)
+			count++
+		}
+	}
+
+	if strict && count > 0 {
+		return fmt.Errorf("store integrity check failed with %d violation(s)", count)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTenantsAreIsolated(t *testing.T) {
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"a1","title":"Tenant A book"}`, "X-Tenant-ID", "a"), http.StatusCreated)
+
+	expectStatus(t, serve(router, http.MethodGet, "/books/a1", "", "X-Tenant-ID", "a"), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodGet, "/books/a1", "", "X-Tenant-ID", "b"), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodGet, "/books/a1", ""), http.StatusNotFound)
+
+	if books := decode[[]book](t, serve(router, http.MethodGet, "/books", "", "X-Tenant-ID", "b")); len(books) != 0 {
+		t.Errorf("tenant b sees %d books, want none", len(books))
+	}
+	if books := decode[[]book](t, serve(router, http.MethodGet, "/books", "", "X-Tenant-ID", "a")); len(books) != 1 {
+		t.Errorf("tenant a sees %d books, want 1", len(books))
+	}
+}
+
+func TestTenantAllowlist(t *testing.T) {
+	t.Setenv("TENANT_ALLOWLIST", "a")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodGet, "/books", "", "X-Tenant-ID", "a"), http.StatusOK)
+	expectStatus(t, serve(router, http.MethodGet, "/books", "", "X-Tenant-ID", "b"), http.StatusForbidden)
+	expectStatus(t, serve(router, http.MethodGet, "/books", ""), http.StatusOK)
+}
+
+func TestReadsDoNotCreateTenants(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, path := range []string{"/books", "/books/1", "/books/availability", "/books/stats", "/authors", "/checkouts/due-today"} {
+		serve(router, http.MethodGet, path, "", "X-Tenant-ID", "ghost")
+	}
+
+	storeMu.RLock()
+	_, ok := libraries["ghost"]
+	storeMu.RUnlock()
+	if ok {
+		t.Error("reads created a library for an unknown tenant")
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"g1","title":"First"}`, "X-Tenant-ID", "ghost"), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodGet, "/books/g1", "", "X-Tenant-ID", "ghost"), http.StatusOK)
+}
+
+func TestVerifyStoreIntegrity(t *testing.T) {
+	resetStore()
+	t.Cleanup(resetStore)
+	if err := verifyStoreIntegrity(true); err != nil {
+		t.Fatalf("seed data fails the integrity check: %v", err)
+	}
+
+	storeMu.Lock()
+	lib := newLibrary([]book{
+		{ID: "1", Title: "One", Quantity: 1},
+		{ID: "1", Title: "Duplicate", Quantity: 1},
+		{ID: "2", Title: "Negative", Quantity: -1},
+	})
+	lib.checkouts = []checkout{{BookID: "9"}}
+	libraries["corrupt"] = lib
+	markStoreChanged()
+	storeMu.Unlock()
+
+	storeMu.RLock()
+	violations := lib.integrityViolations()
+	storeMu.RUnlock()
+	if len(violations) != 3 {
+		t.Errorf("violations = %q, want a duplicate ID, a negative quantity, and an unknown book", violations)
+	}
+
+	if err := verifyStoreIntegrity(false); err != nil {
+		t.Errorf("non-strict check failed: %v", err)
+	}
+	if err := verifyStoreIntegrity(true); err == nil {
+		t.Error("strict check passed corrupt data")
+	}
+}
+
+func TestOnlyModifyingWritesSignalStoreChanges(t *testing.T) {
+	router := newTestRouter(t)
+	start := storeGeneration.Load()
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=missing", ""), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=missing", "", "X-Tenant-ID", "fresh"), http.StatusNotFound)
+	if n := autoReturnOverdue(serverClock.Now(), 0); n != 0 {
+		t.Fatalf("auto-returned %d books, want none", n)
+	}
+	if got := storeGeneration.Load(); got != start {
+		t.Errorf("store generation moved from %d to %d without a change", start, got)
+	}
+
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=1", ""), http.StatusOK)
+	if got := storeGeneration.Load(); got != start+1 {
+		t.Errorf("store generation = %d after a checkout, want %d", got, start+1)
+	}
+}
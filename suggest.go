@@ -0,0 +1,84 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// bookSuggestion is a near-match offered to the client when a book lookup fails.
+type bookSuggestion struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// suggestBooks returns up to limit books of the library whose ID or title resembles query.
+// Prefix matches rank first, then titles containing query, then IDs or titles
+// within a small edit distance of query.
+// Callers must hold storeMu.
+func (l *library) suggestBooks(query string, limit int) []bookSuggestion {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []bookSuggestion{}
+	}
+
+	type candidate struct {
+		book *book
+		rank int
+	}
+
+	maxDistance := len(query) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	var candidates []candidate
+	for i := range l.books {
+		id := strings.ToLower(l.books[i].ID)
+		title := strings.ToLower(l.books[i].Title)
+
+		switch {
+		case strings.HasPrefix(id, query), strings.HasPrefix(title, query):
+			candidates = append(candidates, candidate{&l.books[i], 0})
+		case strings.Contains(title, query):
+			candidates = append(candidates, candidate{&l.books[i], 1})
+		case levenshtein(id, query) <= maxDistance, levenshtein(title, query) <= maxDistance:
+			candidates = append(candidates, candidate{&l.books[i], 2})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].rank < candidates[j].rank
+	})
+
+	suggestions := []bookSuggestion{}
+	for _, cand := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, bookSuggestion{ID: cand.book.ID, Title: cand.book.Title})
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBookByIdSuggestsNearMatches(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/books/golang?suggest=true", "")
+	expectStatus(t, w, http.StatusNotFound)
+	got := decode[errorResponse](t, w).Suggestions
+	want := []bookSuggestion{
+		{ID: "1", Title: "Golang pointers"},
+		{ID: "3", Title: "Golang routers"},
+		{ID: "4", Title: "Golang concurrency"},
+	}
+	if got == nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("suggestions = %v, want %v", got, want)
+	}
+
+	w = serve(router, http.MethodGet, "/books/gorutines?suggest=true", "")
+	if got := decode[errorResponse](t, w).Suggestions; got == nil || len(*got) != 1 || (*got)[0].ID != "2" {
+		t.Errorf("suggestions for a misspelling = %v, want book 2", got)
+	}
+
+	w = serve(router, http.MethodGet, "/books/golang", "")
+	expectStatus(t, w, http.StatusNotFound)
+	if got := decode[errorResponse](t, w).Suggestions; got != nil {
+		t.Errorf("suggestions = %v without suggest=true, want none", *got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"golang", "golang", 0},
+		{"größe", "grose", 2},
+	} {
+		if got := levenshtein(tc.a, tc.b); got != tc.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
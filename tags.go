@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tagFilter selects the books of a bulk tag operation. Both fields match case-insensitively, and a
+// book must match every field that is set.
+type tagFilter struct {
+	Author   string `json:"author"`
+	Category string `json:"category"`
+}
+
+// tagRequest is the JSON payload of tagBooks.
+type tagRequest struct {
+	Filter tagFilter `json:"filter"`
+	Add    []string  `json:"add" binding:"omitempty,dive,required"`
+	Remove []string  `json:"remove" binding:"omitempty,dive,required"`
+}
+
+// tagResponse is the response of tagBooks. Matched is the number of books the filter selected, and
+// Modified the number of them whose tags actually changed.
+type tagResponse struct {
+	Matched  int `json:"matched"`
+	Modified int `json:"modified"`
+}
+
+// matches reports whether the book is selected by the filter.
+func (f tagFilter) matches(b *book) bool {
+	return (f.Author == "" || strings.EqualFold(b.Author, f.Author)) &&
+		(f.Category == "" || strings.EqualFold(b.Category, f.Category))
+}
+
+// retag returns tags with every tag of add that is missing appended and every tag of remove
+// taken out, and whether that changed anything. tags itself is left unchanged.
+func retag(tags, add, remove []string) ([]string, bool) {
+	result := slices.Clone(tags)
+	for _, tag := range add {
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	result = slices.DeleteFunc(result, func(tag string) bool {
+		return slices.Contains(remove, tag)
+	})
+	return result, !slices.Equal(tags, result)
+}
+
+// tagBooks adds tags to and removes tags from every book of the tenant matching a filter, for
+// mass-categorization. It expects a JSON payload in the request body with the following format:
+//
+//	{
+//	  "filter": {"author": "string", "category": "string"},
+//	  "add": ["string"],
+//	  "remove": ["string"]
+//	}
+//
+// Tags that a book already has are not added twice, and tags listed in both "add" and "remove" are removed.
+// It returns the number of matching books and the number of them that were modified, or a 400 status
+// code if the filter is empty or there are no tags to add or remove.
+func tagBooks(c *gin.Context) {
+	var req tagRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lib := currentLibrary(c)
+	now := serverClock.Now()
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	var resp tagResponse
+	for i := range lib.books {
+		b := &lib.books[i]
+		if !req.Filter.matches(b) {
+			continue
+		}
+		resp.Matched++
+
+		tags, changed := retag(b.Tags, req.Add, req.Remove)
+		if !changed {
+			continue
+		}
+		b.Tags = tags
+		b.touch(now)
+		resp.Modified++
+	}
+	if resp.Modified > 0 {
+		markStoreChanged()
+	}
+
+	respondJSON(c, http.StatusOK, resp)
+}
+
+// validate returns an error if the request would tag every book or has no tags to add or remove.
+func (r *tagRequest) validate() error {
+	if r.Filter == (tagFilter{}) {
+		return errors.New("filter must set 'author' or 'category'")
+	}
+	if len(r.Add) == 0 && len(r.Remove) == 0 {
+		return errors.New("at least one tag to 'add' or 'remove' is required")
+	}
+	return nil
+}
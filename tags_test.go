@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestTagBooksByAuthor(t *testing.T) {
+	router := newTestRouter(t)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Generics","author":"Mr. Golang","tags":["go"]}`), http.StatusCreated)
+
+	w := serve(router, http.MethodPost, "/books/tag", `{"filter":{"author":"mr. golang"},"add":["go","language"]}`)
+	expectStatus(t, w, http.StatusOK)
+	if got := decode[tagResponse](t, w); got != (tagResponse{Matched: 2, Modified: 2}) {
+		t.Errorf("response = %+v, want 2 matched and modified", got)
+	}
+
+	want := map[string][]string{"1": {"go", "language"}, "5": {"go", "language"}}
+	for _, b := range decode[[]book](t, serve(router, http.MethodGet, "/books", "")) {
+		if !slices.Equal(b.Tags, want[b.ID]) {
+			t.Errorf("tags of book %s = %v, want %v", b.ID, b.Tags, want[b.ID])
+		}
+	}
+
+	w = serve(router, http.MethodPost, "/books/tag", `{"filter":{"author":"Mr. Golang"},"add":["language"],"remove":["go"]}`)
+	expectStatus(t, w, http.StatusOK)
+	if got := decode[tagResponse](t, w); got != (tagResponse{Matched: 2, Modified: 2}) {
+		t.Errorf("response = %+v, want 2 matched and modified", got)
+	}
+	if b := decode[book](t, serve(router, http.MethodGet, "/books/5", "")); !slices.Equal(b.Tags, []string{"language"}) {
+		t.Errorf("tags of book 5 = %v, want [language]", b.Tags)
+	}
+
+	expectStatus(t, serve(router, http.MethodPost, "/books/tag", `{"filter":{},"add":["everything"]}`), http.StatusBadRequest)
+	expectStatus(t, serve(router, http.MethodPost, "/books/tag", `{"filter":{"author":"Mr. Golang"}}`), http.StatusBadRequest)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName identifies this server in traces.
+const serviceName = "go-rest-api"
+
+// setupTracing installs the global OpenTelemetry tracer provider and W3C trace context propagator.
+// If no OTLP endpoint is configured, the global no-op tracer provider is left in place, so spans
+// are created but never recorded or exported. It returns a function that flushes and stops the
+// exporter, to be called on shutdown.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if otlpEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlpEndpointOptions(otlpEndpoint)...)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// otlpEndpointOptions returns the exporter options that send traces to the collector at the base
+// URL endpoint, which loadConfig has validated. Like the exporter does for the environment variable,
+// traces are posted to the "v1/traces" path below the URL, and plain http disables TLS.
+func otlpEndpointOptions(endpoint string) []otlptracehttp.Option {
+	u, _ := url.Parse(endpoint)
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path.Join(u.Path, "v1/traces")),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return opts
+}
+
+// traceAttributes returns a middleware that annotates the request's span, started by otelgin,
+// with the name of the handler serving it and the ID of the book it operates on, if any.
+func traceAttributes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+
+		name := c.HandlerName()
+		span.SetAttributes(attribute.String("operation", name[strings.LastIndex(name, ".")+1:]))
+
+		id := c.Param("id")
+		if id == "" {
+			id = c.Query("id")
+		}
+		if id != "" {
+			span.SetAttributes(attribute.String("book.id", id))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestsCreateSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	router := newTestRouter(t)
+
+	serve(router, http.MethodGet, "/books/2", "")
+	serve(router, http.MethodPatch, "/checkout?id=3", "")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want one per request", len(spans))
+	}
+	for i, want := range []struct{ operation, id string }{{"bookById", "2"}, {"checkoutBook", "3"}} {
+		attrs := map[attribute.Key]string{}
+		for _, kv := range spans[i].Attributes {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+		if attrs["operation"] != want.operation || attrs["book.id"] != want.id {
+			t.Errorf("span %d has operation %q and book.id %q, want %q and %q", i, attrs["operation"], attrs["book.id"], want.operation, want.id)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bookPatch is a partial update of a book. Fields left out of the JSON payload are nil and
+// leave the corresponding field of the book untouched. A loan_days of 0 restores the default loan period,
+// an empty cover_url removes the cover, and empty available_from and available_until remove the availability window.
+type bookPatch struct {
+	Title            *string   `json:"title"`
+	Author           *string   `json:"author"`
+	ISBN             *string   `json:"isbn"`
+	Category         *string   `json:"category"`
+	Tags             *[]string `json:"tags" binding:"omitempty,unique,dive,required"`
+	CoverURL         *string   `json:"cover_url"`
+	ReservedQuantity *quantity `json:"reserved_quantity" binding:"omitempty,min=0"`
+	MinQuantity      *quantity `json:"min_quantity" binding:"omitempty,min=0"`
+	Quantity         *quantity `json:"quantity" binding:"omitempty,min=0"`
+	LoanDays         *int      `json:"loan_days" binding:"omitempty,min=0"`
+	AvailableFrom    *string   `json:"available_from"`
+	AvailableUntil   *string   `json:"available_until"`
+}
+
+// updateBook replaces the title, author, ISBN, category, tags, cover URL, quantity, reserved and minimum quantity, loan period, and
+// availability window of the book with the ID given in the path.
+// It expects the same JSON payload as createBook; an "id" or "copies" in the payload is ignored.
+// The quantity of a book that tracks copies is derived from its copies and cannot be changed;
+// attempts are rejected with a 422 status code.
+// If the book does not exist and putUpsert is set, it is created with the ID given in the path and
+// the fields of the payload, without applying the defaults of createBook, and returned with status code 201 (Created).
+// It returns the updated book, a 404 status code if the book does not exist and is not created, a 409 status code if
+// unique ISBNs or title and author pairs are enforced and used by another book, or a 412 status code if the
+// request's If-Unmodified-Since precondition fails.
+func updateBook(c *gin.Context) {
+	var input book
+
+	if err := bindBookJSON(c, &input); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateCoverURL(input.CoverURL); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !checkMaxQuantity(c, input.Quantity) {
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	book, err := lib.getBookById(c.Param("id"))
+	if err != nil && putUpsert {
+		createBookAt(c, lib, c.Param("id"), input)
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+
+	if !checkUnmodifiedSince(c, book) {
+		return
+	}
+
+	if !checkISBNAvailable(c, lib, input.ISBN, book.ID) || !checkTitleAuthorAvailable(c, lib, input.Title, input.Author, book.ID) {
+		return
+	}
+
+	if book.tracksCopies() && input.Quantity != book.Quantity {
+		respondError(c, http.StatusUnprocessableEntity, errDerivedQuantity.Error())
+		return
+	}
+	if err := input.validateReserved(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := input.validateWindow(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	book.Title = input.Title
+	book.Author = input.Author
+	book.ISBN = input.ISBN
+	book.Category = input.Category
+	book.Tags = input.Tags
+	book.CoverURL = input.CoverURL
+	book.LoanDays = input.LoanDays
+	book.AvailableFrom = input.AvailableFrom
+	book.AvailableUntil = input.AvailableUntil
+	book.Quantity = input.Quantity
+	book.ReservedQuantity = input.ReservedQuantity
+	book.MinQuantity = input.MinQuantity
+	book.touch(serverClock.Now())
+	markStoreChanged()
+
+	respondJSON(c, http.StatusOK, book)
+}
+
+// createBookAt creates the book described by the payload of updateBook under the given ID, and
+// responds with it with status code 201 (Created). Callers must hold storeMu.
+func createBookAt(c *gin.Context, lib *library, id string, input book) {
+	input.ID = id
+	input.Copies = nil
+	newBook, err := createBookRequest{book: input, Author: &input.Author, Quantity: &input.Quantity}.newBook(serverClock.Now())
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !checkISBNAvailable(c, lib, newBook.ISBN, "") || !checkTitleAuthorAvailable(c, lib, newBook.Title, newBook.Author, "") {
+		return
+	}
+
+	lib.books = append(lib.books, newBook)
+	markStoreChanged()
+	respondJSON(c, http.StatusCreated, newBook)
+}
+
+// patchBook updates only the fields present in the JSON payload of the book with the ID given in the path.
+// A payload sent as application/merge-patch+json is applied as a JSON Merge Patch instead, in which
+// a field set to null is cleared, as described by bindMergePatch.
+// As with updateBook, the quantity of a book that tracks copies cannot be changed.
+// It returns the updated book, a 404 status code if the book does not exist, a 409 status code if
+// unique ISBNs or title and author pairs are enforced and used by another book, or a 412 status code if the
+// request's If-Unmodified-Since precondition fails.
+func patchBook(c *gin.Context) {
+	var patch bookPatch
+
+	var err error
+	if isMergePatch(c) {
+		err = bindMergePatch(c, &patch)
+	} else {
+		err = bindBookJSON(c, &patch)
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if patch.CoverURL != nil {
+		if err := validateCoverURL(*patch.CoverURL); err != nil {
+			respondError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if patch.Quantity != nil && !checkMaxQuantity(c, *patch.Quantity) {
+		return
+	}
+
+	lib := currentLibrary(c)
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	book, err := lib.getBookById(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+
+	if !checkUnmodifiedSince(c, book) {
+		return
+	}
+
+	if patch.ISBN != nil && !checkISBNAvailable(c, lib, *patch.ISBN, book.ID) {
+		return
+	}
+
+	if book.tracksCopies() && patch.Quantity != nil && *patch.Quantity != book.Quantity {
+		respondError(c, http.StatusUnprocessableEntity, errDerivedQuantity.Error())
+		return
+	}
+
+	patched := *book
+	if patch.Title != nil {
+		patched.Title = *patch.Title
+	}
+	if patch.Author != nil {
+		patched.Author = *patch.Author
+	}
+	if !checkTitleAuthorAvailable(c, lib, patched.Title, patched.Author, book.ID) {
+		return
+	}
+
+	if patch.Quantity != nil {
+		patched.Quantity = *patch.Quantity
+	}
+	if patch.ReservedQuantity != nil {
+		patched.ReservedQuantity = *patch.ReservedQuantity
+	}
+	if err := patched.validateReserved(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if patch.AvailableFrom != nil {
+		patched.AvailableFrom = *patch.AvailableFrom
+	}
+	if patch.AvailableUntil != nil {
+		patched.AvailableUntil = *patch.AvailableUntil
+	}
+	if err := patched.validateWindow(); err != nil {
+		respondError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if patch.Title != nil {
+		book.Title = *patch.Title
+	}
+	if patch.Author != nil {
+		book.Author = *patch.Author
+	}
+	if patch.ISBN != nil {
+		book.ISBN = *patch.ISBN
+	}
+	if patch.Category != nil {
+		book.Category = *patch.Category
+	}
+	if patch.Tags != nil {
+		book.Tags = *patch.Tags
+	}
+	if patch.CoverURL != nil {
+		book.CoverURL = *patch.CoverURL
+	}
+	if patch.Quantity != nil {
+		book.Quantity = *patch.Quantity
+	}
+	if patch.ReservedQuantity != nil {
+		book.ReservedQuantity = *patch.ReservedQuantity
+	}
+	if patch.MinQuantity != nil {
+		book.MinQuantity = *patch.MinQuantity
+	}
+	if patch.LoanDays != nil {
+		book.LoanDays = *patch.LoanDays
+	}
+	book.AvailableFrom, book.AvailableUntil = patched.AvailableFrom, patched.AvailableUntil
+	book.touch(serverClock.Now())
+	markStoreChanged()
+
+	respondJSON(c, http.StatusOK, book)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestUpdateBookUpserts(t *testing.T) {
+	router := newTestRouter(t)
+
+	// The ID in the path wins over the one in the payload.
+	const payload = `{"id":"ignored","title":"Chosen ID","author":"Ms. Client","quantity":2}`
+	w := serve(router, http.MethodPut, "/books/isbn-42", payload)
+	expectStatus(t, w, http.StatusCreated)
+	if b := decode[book](t, w); b.ID != "isbn-42" || b.Title != "Chosen ID" || b.Quantity != 2 {
+		t.Errorf("created book = %+v, want book isbn-42 from the payload", b)
+	}
+
+	// Repeating the request replaces the book with the same content.
+	w = serve(router, http.MethodPut, "/books/isbn-42", payload)
+	expectStatus(t, w, http.StatusOK)
+	if got := listIDs(t, router, "/books"); len(got) != 5 {
+		t.Errorf("books = %v after repeating the PUT, want a single new book", got)
+	}
+
+	w = serve(router, http.MethodPut, "/books/1", `{"title":"Replaced","author":"Mr. Golang","quantity":9}`)
+	expectStatus(t, w, http.StatusOK)
+	if b := decode[book](t, w); b.ID != "1" || b.Title != "Replaced" || b.Quantity != 9 {
+		t.Errorf("replaced book = %+v, want book 1 replaced", b)
+	}
+}
+
+func TestUpdateBookWithoutUpsert(t *testing.T) {
+	t.Setenv("PUT_UPSERT", "false")
+	router := newTestRouter(t)
+
+	expectStatus(t, serve(router, http.MethodPut, "/books/5", `{"title":"Missing"}`), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodGet, "/books/5", ""), http.StatusNotFound)
+	expectStatus(t, serve(router, http.MethodPut, "/books/1", `{"title":"Replaced","author":"Mr. Golang","quantity":9}`), http.StatusOK)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// fieldError is a single problem found by validateBook. Field is the JSON path of the offending
+// field, e.g. "copies[0].barcode", or empty if the problem is not tied to a single field.
+type fieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// validationResult is the response of validateBook.
+type validationResult struct {
+	Valid  bool         `json:"valid"`
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+// validateBook checks a book payload like createBook does, without creating the book, so that
+// forms can be validated as the user types. It always responds with status code 200, with
+// {"valid": true} if createBook would accept the payload, or {"valid": false} and the problems found.
+// Whether the ISBN is already in use is not checked, as that depends on the store at the time
+// the book is created.
+func validateBook(c *gin.Context) {
+	var input createBookRequest
+
+	err := bindBookJSON(c, &input)
+	if err == nil {
+		var b book
+		if b, err = input.newBook(serverClock.Now()); err == nil {
+			err = maxQuantityError(b.Quantity)
+		}
+	}
+
+	if err != nil {
+		respondJSON(c, http.StatusOK, validationResult{Errors: fieldErrors(err, reflect.TypeOf(input))})
+		return
+	}
+	respondJSON(c, http.StatusOK, validationResult{Valid: true})
+}
+
+// fieldErrors converts err into field errors. Validation errors of a struct of type t are
+// reported per field, named by their JSON path; any other error is reported as a single
+// error without a field.
+func fieldErrors(err error, t reflect.Type) []fieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []fieldError{{Message: err.Error()}}
+	}
+
+	errs := make([]fieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		errs = append(errs, fieldError{Field: jsonPath(t, fe.StructNamespace()), Message: "failed on the '" + rule + "' rule"})
+	}
+	return errs
+}
+
+// jsonPath translates a validator struct namespace such as "createBookRequest.book.Copies[0].Barcode"
+// of a struct of type t into the JSON path of the field, "copies[0].barcode". Embedded structs
+// do not add to the path.
+func jsonPath(t reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	path := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+
+		name, index, _ := strings.Cut(segment, "[")
+		f, ok := t.FieldByName(name)
+		if !ok {
+			path = append(path, segment)
+			continue
+		}
+		t = f.Type
+		if f.Anonymous {
+			continue
+		}
+
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" {
+			name = tag
+		}
+		if index != "" {
+			name += "[" + index
+		}
+		path = append(path, name)
+	}
+
+	return strings.Join(path, ".")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestValidateBook(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodPost, "/books/validate", `{"id":"5","title":"Valid","quantity":3}`)
+	expectStatus(t, w, http.StatusOK)
+	if got := decode[validationResult](t, w); !reflect.DeepEqual(got, validationResult{Valid: true}) {
+		t.Errorf("result = %+v, want valid", got)
+	}
+
+	w = serve(router, http.MethodPost, "/books/validate", `{"id":"6","title":"Invalid","quantity":-1,"copies":[{"barcode":""}]}`)
+	expectStatus(t, w, http.StatusOK)
+	got := decode[validationResult](t, w)
+	fields := map[string]bool{}
+	for _, fe := range got.Errors {
+		fields[fe.Field] = true
+	}
+	if got.Valid || !fields["quantity"] || !fields["copies[0].barcode"] {
+		t.Errorf("result = %+v, want invalid with errors for quantity and copies[0].barcode", got)
+	}
+
+	w = serve(router, http.MethodPost, "/books/validate", `{"title":"No ID"}`)
+	expectStatus(t, w, http.StatusOK)
+	if got := decode[validationResult](t, w); got.Valid || len(got.Errors) != 1 || got.Errors[0].Field != "" {
+		t.Errorf("result = %+v, want invalid with an error without a field", got)
+	}
+
+	// Nothing was created.
+	expectStatus(t, serve(router, http.MethodGet, "/books/5", ""), http.StatusNotFound)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultWatchTimeout is how long watchBook waits for a change when 'timeout' is not given.
+const defaultWatchTimeout = 30 * time.Second
+
+// maxWatchTimeout is the longest 'timeout' accepted by watchBook.
+const maxWatchTimeout = 5 * time.Minute
+
+// watchResponse is the response of watchBook.
+type watchResponse struct {
+	Changed bool  `json:"changed"`
+	Data    *book `json:"data"`
+}
+
+// watchBook long-polls the book with the ID given in the path, for clients that cannot use
+// server-sent events. It blocks until the book's quantity changes or the 'timeout' query parameter
+// (a duration such as "30s", at most maxWatchTimeout) elapses, and then returns the current book
+// together with whether its quantity changed. Waiting stops as soon as the client disconnects.
+// It returns a 404 status code if the book does not exist or is deleted while waiting, or a 503
+// status code as soon as the server starts shutting down.
+func watchBook(c *gin.Context) {
+	timeout := defaultWatchTimeout
+	if v, ok := c.GetQuery("timeout"); ok {
+		var err error
+		timeout, err = time.ParseDuration(v)
+		if err != nil || timeout <= 0 || timeout > maxWatchTimeout {
+			respondError(c, http.StatusBadRequest, errInvalidQuery("timeout", "a positive duration of at most "+maxWatchTimeout.String()).Error())
+			return
+		}
+	}
+
+	id := c.Param("id")
+	lib := currentLibrary(c)
+
+	storeMu.RLock()
+	b, err := lib.getBookById(id)
+	if err != nil {
+		storeMu.RUnlock()
+		respondError(c, http.StatusNotFound, "book not found")
+		return
+	}
+	initial := b.Quantity
+	changed := storeChanged
+	storeMu.RUnlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		timedOut := false
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-shutdownStarted:
+			setRetryAfter(c, time.Second)
+			respondError(c, http.StatusServiceUnavailable, "server is shutting down")
+			return
+		case <-timer.C:
+			timedOut = true
+		case <-changed:
+		}
+
+		storeMu.RLock()
+		b, err := lib.getBookById(id)
+		if err != nil {
+			storeMu.RUnlock()
+			respondError(c, http.StatusNotFound, "book not found")
+			return
+		}
+		if timedOut || b.Quantity != initial {
+			respondJSON(c, http.StatusOK, watchResponse{Changed: b.Quantity != initial, Data: b})
+			storeMu.RUnlock()
+			return
+		}
+		changed = storeChanged
+		storeMu.RUnlock()
+	}
+}
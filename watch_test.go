@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWatchBookReportsQuantityChange(t *testing.T) {
+	router := newTestRouter(t)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(router, http.MethodGet, "/books/2/watch?timeout=1m", "") }()
+
+	time.Sleep(50 * time.Millisecond)
+	expectStatus(t, serve(router, http.MethodPatch, "/checkout?id=2&user=ann", ""), http.StatusOK)
+
+	select {
+	case w := <-done:
+		expectStatus(t, w, http.StatusOK)
+		if resp := decode[watchResponse](t, w); !resp.Changed || resp.Data.Quantity != 19 {
+			t.Errorf("response = %+v, want changed quantity 19", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not return after the quantity changed")
+	}
+}
+
+func TestWatchBookTimesOutUnchanged(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := serve(router, http.MethodGet, "/books/2/watch?timeout=10ms", "")
+	expectStatus(t, w, http.StatusOK)
+	if resp := decode[watchResponse](t, w); resp.Changed {
+		t.Errorf("response = %+v, want unchanged", resp)
+	}
+}
+
+func TestWatchBookReturnsWhenShutdownStarts(t *testing.T) {
+	router := newTestRouter(t)
+	t.Cleanup(func() {
+		draining.Store(false)
+		shutdownStarted = make(chan struct{})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(router, http.MethodGet, "/books/2/watch?timeout=1m", "") }()
+
+	time.Sleep(50 * time.Millisecond)
+	startDraining()
+
+	select {
+	case w := <-done:
+		expectStatus(t, w, http.StatusServiceUnavailable)
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("missing Retry-After header")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not return when the shutdown started")
+	}
+}
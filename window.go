@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeOfDayLayout is the format of the times of day of a book's availability window, e.g. "09:30".
+const timeOfDayLayout = "15:04"
+
+// hasWindow reports whether the book can only be checked out during its availability window.
+func (b *book) hasWindow() bool {
+	return b.AvailableFrom != "" || b.AvailableUntil != ""
+}
+
+// validateWindow returns an error unless the book has no availability window, or a window whose
+// available_from and available_until are both set to different times of day in HH:MM format.
+func (b *book) validateWindow() error {
+	if !b.hasWindow() {
+		return nil
+	}
+	if b.AvailableFrom == "" || b.AvailableUntil == "" {
+		return errors.New("available_from and available_until must be set together")
+	}
+
+	from, err := parseTimeOfDay(b.AvailableFrom)
+	if err != nil {
+		return fmt.Errorf("invalid available_from '%s': must be a time of day in HH:MM format", b.AvailableFrom)
+	}
+	until, err := parseTimeOfDay(b.AvailableUntil)
+	if err != nil {
+		return fmt.Errorf("invalid available_until '%s': must be a time of day in HH:MM format", b.AvailableUntil)
+	}
+	if from == until {
+		return errors.New("available_from and available_until must differ")
+	}
+	return nil
+}
+
+// parseTimeOfDay returns the minutes since midnight of a time of day in strict HH:MM format, with
+// two-digit hours and minutes. Looser forms that time.Parse accepts, such as "9:00", are rejected.
+func parseTimeOfDay(s string) (int, error) {
+	t, err := time.Parse(timeOfDayLayout, s)
+	if err != nil {
+		return 0, err
+	}
+	if t.Format(timeOfDayLayout) != s {
+		return 0, fmt.Errorf("'%s' is not in HH:MM format", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// inWindow reports whether t (in UTC) falls within the book's availability window, which starts at
+// available_from and ends before available_until. A window whose end is before its start spans
+// midnight, e.g. 22:00 to 06:00. A book without a window is always available.
+func (b *book) inWindow(t time.Time) bool {
+	if !b.hasWindow() {
+		return true
+	}
+
+	// The window was validated when it was set, so it parses.
+	from, _ := parseTimeOfDay(b.AvailableFrom)
+	until, _ := parseTimeOfDay(b.AvailableUntil)
+
+	t = t.UTC()
+	now := t.Hour()*60 + t.Minute()
+	if from < until {
+		return now >= from && now < until
+	}
+	return now >= from || now < until
+}
+
+// checkWindow reports whether the book may be checked out at now. If now is outside the book's
+// availability window, it responds with status code 403 (Forbidden) and returns false.
+func checkWindow(c *gin.Context, b *book, now time.Time) bool {
+	if b.inWindow(now) {
+		return true
+	}
+	respondError(c, http.StatusForbidden, fmt.Sprintf("book can only be checked out between %s and %s UTC; it becomes available at %s UTC",
+		b.AvailableFrom, b.AvailableUntil, b.AvailableFrom))
+	return false
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheckoutWithinAvailabilityWindow(t *testing.T) {
+	router := newTestRouter(t)
+	clk := stopClock(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC))
+
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"5","title":"Rare","quantity":5,"available_from":"09:00","available_until":"17:00"}`), http.StatusCreated)
+	expectStatus(t, serve(router, http.MethodPost, "/books", `{"id":"6","title":"Night","quantity":5,"available_from":"22:00","available_until":"06:00"}`), http.StatusCreated)
+
+	for _, tc := range []struct {
+		id     string
+		at     string
+		status int
+	}{
+		{"5", "08:00", http.StatusForbidden},
+		{"5", "08:59", http.StatusForbidden},
+		{"5", "09:00", http.StatusOK},
+		{"5", "16:59", http.StatusOK},
+		{"5", "17:00", http.StatusForbidden},
+		{"6", "23:30", http.StatusOK},
+		{"6", "05:59", http.StatusOK},
+		{"6", "12:00", http.StatusForbidden},
+	} {
+		at, _ := time.Parse(timeOfDayLayout, tc.at)
+		clk.now = time.Date(2024, 3, 1, at.Hour(), at.Minute(), 0, 0, time.UTC)
+
+		w := serve(router, http.MethodPatch, "/checkout?id="+tc.id, "")
+		if w.Code != tc.status {
+			t.Errorf("checkout of %s at %s: status = %d, want %d; body: %s", tc.id, tc.at, w.Code, tc.status, w.Body.String())
+		}
+	}
+}
+
+func TestAvailabilityWindowRequiresStrictTimes(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, window := range []string{
+		`"available_from":"9:00","available_until":"17:00"`,
+		`"available_from":"09:00","available_until":"5:00"`,
+		`"available_from":"24:00","available_until":"05:00"`,
+		`"available_from":"09:00"`,
+		`"available_from":"09:00","available_until":"09:00"`,
+	} {
+		w := serve(router, http.MethodPost, "/books", `{"id":"5","title":"Rare",`+window+`}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("window {%s}: status = %d, want 400", window, w.Code)
+		}
+	}
+}